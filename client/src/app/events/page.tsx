@@ -1,7 +1,7 @@
 "use client";
 
 import { useState, useEffect, useCallback } from "react";
-import { getEvents, deleteEvent, fetchNewsArticle, addMilestone, getMilestones } from "@/utils/api";
+import { getEvents, deleteEvent, streamNewsArticles, addMilestone, getMilestones } from "@/utils/api";
 import { useSearchParams, useRouter } from "next/navigation";
 import CreateEventModal from "./create/page";
 import EventDetailModal from "./[id]/page";
@@ -62,9 +62,13 @@ export default function EventsPage() {
     const fetchNews = useCallback(async () => {
         setNewsLoading(true);
         setNewsError(null);
+        setNews([]);
         try {
-            const data = await fetchNewsArticle();
-            setNews(data);
+            // The headlines are shown as they are fetched, then replaced by the saved ones
+            const summary = await streamNewsArticles({
+                article: (article) => setNews((prev) => [...prev, article]),
+            });
+            setNews(summary.articles);
         } catch (err) {
             setNewsError("Failed to fetch news");
         } finally {
@@ -1,13 +1,14 @@
 "use client";
 
 import { useState } from "react";
-import { fetchStockData, fetchStockNews } from "@/utils/api";
+import { fetchStockData, streamStockNews } from "@/utils/api";
 
 export default function StocksPage() {
     const [code, setCode] = useState("");
     const [data, setData] = useState<any>(null);
     const [news, setNews] = useState<any[]>([]);
     const [loading, setLoading] = useState(false);
+    const [newsPage, setNewsPage] = useState<number | null>(null); // News page being read
     const [error, setError] = useState("");
     const [history, setHistory] = useState<string[]>([]); // Save search history
 
@@ -22,12 +23,22 @@ export default function StocksPage() {
         setData(null);
         setNews([]);
 
-        try {
-            const stockData = await fetchStockData(stockCode);
-            const stockNews = await fetchStockNews(stockCode);
+        // The news are shown as they are read, while the stock data is fetched
+        setNewsPage(0);
+        const newsStream = streamStockNews(stockCode, {
+            page: (progress) => setNewsPage(progress.page),
+            article: (article) => setNews((prev) => [...prev, article]),
+        });
 
-            setData(stockData);
-            setNews(stockNews);
+        try {
+            const [, summary] = await Promise.all([
+                fetchStockData(stockCode).then((stockData) => {
+                    setData(stockData);
+                    setLoading(false);
+                }),
+                newsStream,
+            ]);
+            setNews(summary.articles);
 
             setHistory((prev) =>
                 prev.includes(stockCode) ? prev : [...prev, stockCode]
@@ -36,9 +47,15 @@ export default function StocksPage() {
             setError(err.message);
         } finally {
             setLoading(false);
+            setNewsPage(null);
         }
     };
 
+    const formatChange = (change: number, percent: number) => {
+        const sign = change > 0 ? "+" : "";
+        return `${sign}${change.toLocaleString()} (${sign}${percent.toFixed(2)}%)`;
+    };
+
     const highlightLink = (title: string) => {
         return title.includes("決算") ? "bg-sky-500 text-white font-bold px-2 py-1 rounded inline-block" : "";
     };
@@ -88,6 +105,7 @@ export default function StocksPage() {
             )}
 
             {loading && <p>データ取得中...</p>}
+            {newsPage !== null && <p>ニュース取得中... ({newsPage}ページ)</p>}
             {error && <p className="text-red-500">{error}</p>}
 
             <div className="grid grid-cols-1 md:grid-cols-2 gap-6">
@@ -95,12 +113,12 @@ export default function StocksPage() {
                     {data && (
                         <div className="border rounded p-4">
                             <h2 className="text-xl font-bold">銘柄コード: {data.stockData.code}</h2>
-                            <p>株価: &yen; {data.stockData.stock_price} </p>
-                            <p>前日終値: &yen; {data.stockData.prev_close}</p>
-                            <p>変動額: {data.stockData.price_change}</p>
+                            <p>株価: &yen; {data.stockData.stock_price.toLocaleString()} </p>
+                            <p>前日終値: &yen; {data.stockData.prev_close.toLocaleString()}</p>
+                            <p>変動額: {formatChange(data.stockData.price_change, data.stockData.price_change_percent)}</p>
                             <p>STOP高: {data.stockData.stop_high ? "あり" : "なし"}</p>
-                            <p>時価総額: &yen; {data.stockData.market_cap} </p>
-                            <p>発行済株数: {data.stockData.issued_shares} 株</p>
+                            <p>時価総額: &yen; {data.stockData.market_cap.toLocaleString()} </p>
+                            <p>発行済株数: {data.stockData.issued_shares.toLocaleString()} 株</p>
                             <p>平均PER: {data.stockData.average_per}</p>
                             <p>平均PBR: {data.stockData.average_pbr}</p>
                         </div>
@@ -9,6 +9,30 @@ const apiClient = axios.create({
     // timeout: 5000,
 });
 
+// Handlers of the events of a stream, by event name
+type StreamHandlers = Record<string, (data: any) => void>;
+
+// Read the Server-Sent Events of a long scrape until its "summary" event
+// Each event is passed to its handler as it arrives; an "error" event or a lost connection rejects
+const streamEvents = (path: string, handlers: StreamHandlers): Promise<any> => {
+    return new Promise((resolve, reject) => {
+        const source = new EventSource(`${process.env.NEXT_PUBLIC_API_BASE_URL}${path}`);
+        for (const [event, handler] of Object.entries(handlers)) {
+            source.addEventListener(event, (e) => handler(JSON.parse((e as MessageEvent).data)));
+        }
+        source.addEventListener("summary", (e) => {
+            source.close();
+            resolve(JSON.parse((e as MessageEvent).data));
+        });
+        source.addEventListener("error", (e) => {
+            source.close();
+            const data = (e as MessageEvent).data;
+            console.error("Stream Error:", data);
+            reject(new Error(data ? JSON.parse(data).error : "Connection lost"));
+        });
+    });
+};
+
 // Fetch Data
 export const fetchData = async (): Promise<{ message: string }> => {
     try {
@@ -68,6 +92,68 @@ export const fetchStockNews = async (code: string) => {
     return response.data;
 };
 
+// Stream Stock News as the news pages are read, with "page" and "article" handlers
+// Resolves with the summary, whose articles are the same as fetchStockNews
+export const streamStockNews = (code: string, handlers: StreamHandlers) => {
+    return streamEvents(`/stocks/${code}/news/stream`, handlers);
+};
+
+// Fetch Stock Price History
+export const fetchStockHistory = async (code: string, from?: string, to?: string) => {
+    const response = await apiClient.get(`/stocks/${code}/history`, { params: { from, to } });
+    return response.data;
+};
+
+// Watchlist Functions
+// Fetch Watchlist with cached quotes
+export const getWatchlist = async () => {
+    const response = await apiClient.get("/watchlist");
+    return response.data;
+};
+
+// Add Stock to Watchlist
+export const addWatchlistItem = async (code: number) => {
+    const response = await apiClient.post("/watchlist", { stock_code: code });
+    return response.data;
+};
+
+// Update the Note and Sort Order of a Watchlist Item
+export const updateWatchlistItem = async (code: number, item: { note: string; sort_order: number }) => {
+    const response = await apiClient.put(`/watchlist/${code}`, item);
+    return response.data;
+};
+
+// Remove Stock from Watchlist
+export const removeWatchlistItem = async (code: number) => {
+    const response = await apiClient.delete(`/watchlist/${code}`);
+    return response.data;
+};
+
+// Portfolio Functions
+// Fetch Portfolios
+export const getPortfolios = async () => {
+    const response = await apiClient.get("/portfolios");
+    return response.data;
+};
+
+// Fetch Portfolio with valued holdings and P&L
+export const getPortfolio = async (id: string) => {
+    const response = await apiClient.get(`/portfolios/${id}`);
+    return response.data;
+};
+
+// Create Portfolio
+export const createPortfolio = async (portfolio: any) => {
+    const response = await apiClient.post("/portfolios", portfolio);
+    return response.data;
+};
+
+// Record Trade
+export const createTrade = async (id: string, trade: any) => {
+    const response = await apiClient.post(`/portfolios/${id}/trades`, trade);
+    return response.data;
+};
+
 // Fetch Bloomberg News
 export const fetchNewsArticle = async () => {
     try {
@@ -79,6 +165,11 @@ export const fetchNewsArticle = async () => {
     }
 };
 
+// Stream Bloomberg News as the sources are fetched, with "source" and "article" handlers
+export const streamNewsArticles = (handlers: StreamHandlers) => {
+    return streamEvents("/bloomberg/stream", handlers);
+};
+
 // Search Bloomberg News
 export const searchNewsArticles = async (query: string) => {
     const response = await apiClient.get(`/bloomberg/search?q=${encodeURIComponent(query)}`);
@@ -0,0 +1,102 @@
+// Package alerts checks fetched quotes against the alert rules
+// and puts the triggered ones on the event board.
+package alerts
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"server/db"
+	"server/models"
+	"server/repository"
+)
+
+// Matches reports whether the quote meets the condition of the rule
+func Matches(rule models.AlertRule, quote *models.StockQuote) bool {
+	switch rule.Condition {
+	case models.PriceAbove:
+		return quote.StockPrice > 0 && quote.StockPrice >= rule.Threshold
+	case models.PriceBelow:
+		return quote.StockPrice > 0 && quote.StockPrice <= rule.Threshold
+	case models.ChangePercent:
+		return quote.PriceChangePercent >= rule.Threshold || quote.PriceChangePercent <= -rule.Threshold
+	case models.StopHighHit:
+		return quote.StopHigh
+	case models.PERAbove:
+		return quote.AveragePER > 0 && quote.AveragePER >= rule.Threshold
+	}
+	return false
+}
+
+// Describe the condition for the event title
+func describe(rule models.AlertRule, quote *models.StockQuote) string {
+	switch rule.Condition {
+	case models.PriceAbove:
+		return fmt.Sprintf("株価が%.0f円以上 (現在 %.0f円)", rule.Threshold, quote.StockPrice)
+	case models.PriceBelow:
+		return fmt.Sprintf("株価が%.0f円以下 (現在 %.0f円)", rule.Threshold, quote.StockPrice)
+	case models.ChangePercent:
+		return fmt.Sprintf("前日比 %+.2f%% (閾値 ±%.2f%%)", quote.PriceChangePercent, rule.Threshold)
+	case models.StopHighHit:
+		return "STOP高"
+	case models.PERAbove:
+		return fmt.Sprintf("平均PERが%.2f以上 (現在 %.2f)", rule.Threshold, quote.AveragePER)
+	}
+	return string(rule.Condition)
+}
+
+// Evaluate checks the quote against the enabled rules of its stock.
+// A rule triggers at most once per trading day; each trigger creates an urgent event.
+func Evaluate(quote *models.StockQuote) ([]models.Event, error) {
+	return EvaluateAt(quote, time.Now())
+}
+
+// EvaluateAt evaluates the quote as of now, whose JST date is the trading day of the triggers
+func EvaluateAt(quote *models.StockQuote, now time.Time) ([]models.Event, error) {
+	rules, err := repository.GetEnabledAlertRules(quote.StockCode)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+
+	name := fmt.Sprint(quote.StockCode)
+	var stock models.Stock
+	if err := db.DB.Where("stock_code = ?", quote.StockCode).First(&stock).Error; err == nil {
+		name = fmt.Sprintf("%d %s", stock.StockCode, stock.StockName)
+	}
+
+	today := repository.TradingDate(now)
+
+	var events []models.Event
+	for _, rule := range rules {
+		if !Matches(rule, quote) {
+			continue
+		}
+		if rule.LastTriggeredAt != nil && !repository.TradingDate(*rule.LastTriggeredAt).Before(today) {
+			continue
+		}
+
+		deadline := now.AddDate(0, 0, rule.DeadlineDays)
+		event := models.Event{
+			Title:       fmt.Sprintf("[Alert] %s: %s", name, describe(rule, quote)),
+			Description: fmt.Sprintf("Alert rule #%d (%s) triggered by the quote of %s", rule.ID, rule.Condition, quote.TradingDate.Format("2006/01/02")),
+			StartTime:   now,
+			EndTime:     deadline,
+			Deadline:    deadline,
+			Status:      models.ToDo,
+			Tag:         models.Urgent,
+		}
+		created, err := repository.TriggerAlertRule(&rule, &event, now)
+		if err != nil {
+			return events, err
+		}
+		if !created {
+			// Triggered by a concurrent evaluation
+			continue
+		}
+
+		log.Printf("Alert rule #%d triggered for %s, event #%d created", rule.ID, name, event.ID)
+		events = append(events, event)
+	}
+	return events, nil
+}
@@ -0,0 +1,91 @@
+package alerts_test
+
+import (
+	"testing"
+	"time"
+
+	"server/alerts"
+	"server/db"
+	"server/jpdate"
+	"server/models"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition models.AlertCondition
+		threshold float64
+		quote     models.StockQuote
+		want      bool
+	}{
+		{"above at the threshold", models.PriceAbove, 2000, models.StockQuote{StockPrice: 2000}, true},
+		{"above over the threshold", models.PriceAbove, 2000, models.StockQuote{StockPrice: 2100}, true},
+		{"above under the threshold", models.PriceAbove, 2000, models.StockQuote{StockPrice: 1999}, false},
+		{"above without a price", models.PriceAbove, 0, models.StockQuote{}, false},
+		{"below at the threshold", models.PriceBelow, 1500, models.StockQuote{StockPrice: 1500}, true},
+		{"below under the threshold", models.PriceBelow, 1500, models.StockQuote{StockPrice: 1400}, true},
+		{"below over the threshold", models.PriceBelow, 1500, models.StockQuote{StockPrice: 1501}, false},
+		{"below without a price", models.PriceBelow, 1500, models.StockQuote{}, false},
+		{"change up to the threshold", models.ChangePercent, 5, models.StockQuote{StockPrice: 1050, PriceChangePercent: 5}, true},
+		{"change down past the threshold", models.ChangePercent, 5, models.StockQuote{StockPrice: 940, PriceChangePercent: -6}, true},
+		{"change within the threshold", models.ChangePercent, 5, models.StockQuote{StockPrice: 1040, PriceChangePercent: 4}, false},
+		{"change down within the threshold", models.ChangePercent, 5, models.StockQuote{StockPrice: 960, PriceChangePercent: -4.99}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := models.AlertRule{Condition: tt.condition, Threshold: tt.threshold}
+			if got := alerts.Matches(rule, &tt.quote); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// A rule triggers once per trading day in JST, whatever the date in UTC
+func TestEvaluateOncePerTradingDay(t *testing.T) {
+	database, err := db.Open("file:" + t.Name() + "?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.DB = database
+
+	rule := models.AlertRule{StockCode: 4385, Condition: models.PriceAbove, Threshold: 2000, DeadlineDays: 1, Enabled: true}
+	if err := db.DB.Create(&rule).Error; err != nil {
+		t.Fatal(err)
+	}
+	quote := &models.StockQuote{StockCode: 4385, StockPrice: 2100}
+
+	jst := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 3, day, hour, minute, 0, 0, jpdate.JST)
+	}
+	steps := []struct {
+		name    string
+		at      time.Time
+		trigger bool
+	}{
+		{"first match of the day", jst(17, 8, 0), true}, // 2025-03-16 23:00 UTC
+		{"same JST day, next UTC day", jst(17, 10, 0), false},
+		{"just before midnight in JST", jst(17, 23, 59), false},
+		{"just after midnight in JST, same UTC day", jst(18, 0, 1), true},
+		{"later on the new day", jst(18, 15, 0), false},
+	}
+	for _, step := range steps {
+		events, err := alerts.EvaluateAt(quote, step.at)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := len(events) == 1; got != step.trigger || len(events) > 1 {
+			t.Fatalf("%s: %d events, want trigger %v", step.name, len(events), step.trigger)
+		}
+		if step.trigger && !events[0].Deadline.Equal(step.at.AddDate(0, 0, 1)) {
+			t.Errorf("%s: deadline %s, want a day after %s", step.name, events[0].Deadline, step.at)
+		}
+	}
+
+	var triggers, events int64
+	db.DB.Model(&models.AlertTrigger{}).Count(&triggers)
+	db.DB.Model(&models.Event{}).Count(&events)
+	if triggers != 2 || events != 2 {
+		t.Errorf("%d triggers and %d events saved, want 2 of each", triggers, events)
+	}
+}
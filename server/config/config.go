@@ -0,0 +1,12 @@
+// Package config reads the settings shared by the server and the crawler from the environment.
+package config
+
+import "os"
+
+// Getenv returns the value of an environment variable, or fallback if it is unset or empty
+func Getenv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"fmt"
 	"log"
 	"server/models"
 
@@ -13,22 +14,47 @@ var DB *gorm.DB
 // Init DB
 func InitDB() *gorm.DB {
 	var err error
-	DB, err = gorm.Open(sqlite.Open("steps.db"), &gorm.Config{})
+	DB, err = Open("steps.db")
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		log.Fatal(err)
+	}
+
+	return DB
+}
+
+// Open the SQLite database at path and migrate its tables.
+// Other programs such as the crawler share the database of the server this way.
+func Open(path string) (*gorm.DB, error) {
+	database, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	// Auto migration of the tables
-	if err := DB.AutoMigrate(
+	if err := database.AutoMigrate(
 		&models.User{},
 		&models.Event{},
 		&models.NewsArticle{},
+		&models.NewsSource{},
+		&models.ArticleStock{},
 		&models.Milestone{},
 		&models.Stock{},
 		&models.StockDetail{},
+		&models.StockMasterChange{},
+		&models.StockMasterImport{},
+		&models.StockQuote{},
+		&models.StockDisclosure{},
+		&models.StockNewsCoverage{},
+		&models.WatchlistItem{},
+		&models.ScrapeRun{},
+		&models.Portfolio{},
+		&models.Trade{},
+		&models.Holding{},
+		&models.AlertRule{},
+		&models.AlertTrigger{},
 	); err != nil {
-		log.Fatal("Migration failed:", err)
+		return nil, fmt.Errorf("migration failed: %w", err)
 	}
 
-	return DB
+	return database, nil
 }
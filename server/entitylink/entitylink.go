@@ -0,0 +1,211 @@
+// Package entitylink finds the stocks that news articles and milestones
+// mention by name or code, and stores them as article_stocks.
+//
+// A stock is mentioned by its stock name, its company name without 株式会社, its English
+// company name, or its code in brackets, e.g. （4385）. English names count as whole words,
+// and Japanese names of two characters only where no other kanji or katakana adjoin them.
+// The linker of the listed stocks is built again only after a stock master import.
+package entitylink
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"server/models"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Shortest names that are matched; shorter ones are mostly common words or abbreviations
+const (
+	minNameRunes  = 2 // Japanese names, e.g. 花王
+	minASCIIBytes = 4 // English names, matched as whole words
+)
+
+// Japanese names this short are part of many words, e.g. 明治 of 明治時代,
+// so they only count where no kanji or katakana runs on before or after them
+const shortNameRunes = 2
+
+// Codes written after a company name, e.g. メルカリ（4385）or <4385>
+var codePattern = regexp.MustCompile(`[(<\[](\d{4})[)>\]]`)
+
+// Legal forms left out of the company names, as articles rarely write them
+var (
+	japaneseLegalForms = []string{"株式会社", "(株)"}
+	englishLegalForms  = []string{"co.,ltd.", "co., ltd.", "company limited", "corporation", "corp.", "inc.", "ltd.", "limited", "k.k."}
+)
+
+// Match is a stock mentioned in a text
+type Match struct {
+	StockCode int
+	StockName string
+	Name      string // Name or code found in the text, normalized
+}
+
+// A name of one or more stocks, in the normalized form it is searched for
+type candidate struct {
+	pattern string
+	ascii   bool
+	short   bool // Matched only as a whole token
+	stocks  []models.Stock
+}
+
+// Linker matches texts against the names of a set of stocks
+type Linker struct {
+	candidates []candidate // Longest first, so that a longer name wins over the names inside it
+	codes      map[int]models.Stock
+}
+
+// New builds a linker for the stock names, company names and English company names of the stocks
+func New(stocks []models.Stock) *Linker {
+	l := &Linker{codes: map[int]models.Stock{}}
+	byPattern := map[string]int{}
+	for _, stock := range stocks {
+		l.codes[stock.StockCode] = stock
+		for _, name := range stockNames(stock) {
+			pattern := normalize(name)
+			ascii := isASCII(pattern)
+			if (ascii && len(pattern) < minASCIIBytes) || utf8.RuneCountInString(pattern) < minNameRunes {
+				continue
+			}
+
+			i, ok := byPattern[pattern]
+			if !ok {
+				i = len(l.candidates)
+				byPattern[pattern] = i
+				short := !ascii && utf8.RuneCountInString(pattern) <= shortNameRunes
+				l.candidates = append(l.candidates, candidate{pattern: pattern, ascii: ascii, short: short})
+			}
+			if c := &l.candidates[i]; !containsStock(c.stocks, stock.StockCode) {
+				c.stocks = append(c.stocks, stock)
+			}
+		}
+	}
+	sort.SliceStable(l.candidates, func(i, j int) bool {
+		return len(l.candidates[i].pattern) > len(l.candidates[j].pattern)
+	})
+	return l
+}
+
+// Match returns the stocks mentioned in the texts, once each in the order of their codes.
+// Where names overlap, e.g. トヨタ自動車 and トヨタ, only the longest one counts.
+func (l *Linker) Match(texts ...string) []Match {
+	text := normalize(strings.Join(texts, "\n"))
+	covered := make([]bool, len(text))
+	found := map[int]Match{}
+
+	for _, c := range l.candidates {
+		for offset := 0; offset < len(text); {
+			i := strings.Index(text[offset:], c.pattern)
+			if i < 0 {
+				break
+			}
+			start, end := offset+i, offset+i+len(c.pattern)
+			offset = end
+			if (c.ascii && !isWord(text, start, end)) || (c.short && !isToken(text, start, end)) || isCovered(covered, start, end) {
+				continue
+			}
+			for j := start; j < end; j++ {
+				covered[j] = true
+			}
+			for _, stock := range c.stocks {
+				if _, ok := found[stock.StockCode]; !ok {
+					found[stock.StockCode] = Match{StockCode: stock.StockCode, StockName: stock.StockName, Name: text[start:end]}
+				}
+			}
+		}
+	}
+
+	for _, m := range codePattern.FindAllStringSubmatch(text, -1) {
+		code, _ := strconv.Atoi(m[1])
+		stock, ok := l.codes[code]
+		if _, linked := found[code]; ok && !linked {
+			found[code] = Match{StockCode: code, StockName: stock.StockName, Name: m[1]}
+		}
+	}
+
+	matches := make([]Match, 0, len(found))
+	for _, m := range found {
+		matches = append(matches, m)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].StockCode < matches[j].StockCode })
+	return matches
+}
+
+// Names of a stock that articles use, without the legal forms
+func stockNames(stock models.Stock) []string {
+	names := []string{stock.StockName}
+	company := normalize(stock.CompanyName)
+	for _, form := range japaneseLegalForms {
+		company = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(company, form), form))
+	}
+	names = append(names, company)
+
+	english := normalize(stock.EnglishCompanyName)
+	for trimmed := true; trimmed; {
+		trimmed = false
+		for _, form := range englishLegalForms {
+			if strings.HasSuffix(english, form) {
+				english = strings.TrimRight(strings.TrimSuffix(english, form), " ,")
+				trimmed = true
+			}
+		}
+	}
+	return append(names, english)
+}
+
+// Fold the full-width letters and digits and the half-width kana (NFKC), and the case
+func normalize(s string) string {
+	return strings.ToLower(strings.TrimSpace(norm.NFKC.String(s)))
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// Whether text[start:end] is not part of a longer English word or number
+func isWord(text string, start, end int) bool {
+	return (start == 0 || !isAlnum(text[start-1])) && (end == len(text) || !isAlnum(text[end]))
+}
+
+// Whether text[start:end] is not part of a longer run of kanji and katakana, e.g. 花王が but not 明治時代
+func isToken(text string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	return !isWordRune(before) && !isWordRune(after)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Katakana) || r == 'ー'
+}
+
+func isAlnum(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
+
+func isCovered(covered []bool, start, end int) bool {
+	for i := start; i < end; i++ {
+		if covered[i] {
+			return true
+		}
+	}
+	return false
+}
+
+func containsStock(stocks []models.Stock, code int) bool {
+	for _, stock := range stocks {
+		if stock.StockCode == code {
+			return true
+		}
+	}
+	return false
+}
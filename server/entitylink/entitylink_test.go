@@ -0,0 +1,60 @@
+package entitylink_test
+
+import (
+	"reflect"
+	"testing"
+
+	"server/entitylink"
+	"server/models"
+)
+
+func TestMatch(t *testing.T) {
+	linker := entitylink.New([]models.Stock{
+		{StockCode: 7203, StockName: "トヨタ自動車", CompanyName: "トヨタ自動車株式会社", EnglishCompanyName: "TOYOTA MOTOR CORPORATION"},
+		{StockCode: 9990, StockName: "トヨタ"},
+		{StockCode: 4385, StockName: "メルカリ", CompanyName: "株式会社メルカリ", EnglishCompanyName: "Mercari, Inc."},
+		{StockCode: 9433, StockName: "KDDI", CompanyName: "KDDI株式会社", EnglishCompanyName: "KDDI CORPORATION"},
+		{StockCode: 6758, StockName: "ソニーグループ", EnglishCompanyName: "Sony Group Corporation"},
+		{StockCode: 4452, StockName: "花王", CompanyName: "花王(株)"},
+		{StockCode: 2269, StockName: "明治HD", CompanyName: "明治"},
+		{StockCode: 9991, StockName: "旭"},
+		{StockCode: 9992, StockName: "ANA"},
+	})
+
+	tests := []struct {
+		name string
+		text string
+		want []int
+	}{
+		{"longest name covers the names inside it", "トヨタ自動車が増産", []int{7203}},
+		{"shorter name alone", "トヨタの販売店", []int{9990}},
+		{"both names apart", "トヨタ自動車とトヨタ", []int{7203, 9990}},
+		{"legal form stripped from the company name", "メルカリ株式会社", []int{4385}},
+		{"legal form stripped from the English name", "Mercari announced results", []int{4385}},
+		{"English name as a whole word", "Shares of KDDI rose", []int{9433}},
+		{"English name inside a longer word", "KDDIX and XKDDI", nil},
+		{"English name with the legal form stripped", "sony group shares", []int{6758}},
+		{"English name inside a longer phrase", "Sony Groupies", nil},
+		{"full-width letters folded", "ＫＤＤＩの決算", []int{9433}},
+		{"short name as a token", "花王が値上げ", []int{4452}},
+		{"short name at the end", "決算発表は花王", []int{4452}},
+		{"short name inside a word", "明治時代の建物", nil},
+		{"short name before katakana", "花王カップ", nil},
+		{"short company name as a token", "明治の新商品", []int{2269}},
+		{"name below the minimum", "旭が昇る", nil},
+		{"English name below the minimum", "ANA flights", nil},
+		{"code in brackets", "決算短信（4385）", []int{4385}},
+		{"code of an unknown stock", "決算短信（1234）", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int
+			for _, m := range linker.Match(tt.text) {
+				got = append(got, m.StockCode)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Match(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package entitylink
+
+import (
+	"server/db"
+	"server/models"
+	"server/repository"
+	"sync"
+)
+
+// Master data a linker was built from, changed by each stock master import or crawl
+type masterVersion struct {
+	importID uint
+	finished bool
+}
+
+// The linker of the listed stocks, shared by the news and the milestones until the master data changes
+var cache struct {
+	sync.Mutex
+	linker  *Linker
+	version masterVersion
+}
+
+// Load returns the linker of the listed stocks, built again only after a stock master import
+func Load() (*Linker, error) {
+	latest, err := repository.GetLatestStockMasterImport()
+	if err != nil {
+		return nil, err
+	}
+	version := masterVersion{importID: latest.ID, finished: latest.FinishedAt != nil}
+
+	cache.Lock()
+	defer cache.Unlock()
+	if cache.linker != nil && cache.version == version {
+		return cache.linker, nil
+	}
+
+	stocks, err := repository.GetListedStocks()
+	if err != nil {
+		return nil, err
+	}
+	cache.linker, cache.version = New(stocks), version
+	return cache.linker, nil
+}
+
+// LinkNewsArticles links the saved articles to the stocks their title and description mention
+func LinkNewsArticles(articles []models.NewsArticle) error {
+	l, err := Load()
+	if err != nil {
+		return err
+	}
+	return l.LinkNewsArticles(articles)
+}
+
+// LinkMilestones links the saved milestones to the stocks their title mentions
+func LinkMilestones(items []models.Milestone) error {
+	l, err := Load()
+	if err != nil {
+		return err
+	}
+	return l.LinkMilestones(items)
+}
+
+func (l *Linker) LinkNewsArticles(articles []models.NewsArticle) error {
+	links := map[uint][]models.ArticleStock{}
+	for _, article := range articles {
+		links[article.ID] = articleStocks(l.Match(article.Title, article.Description))
+	}
+	return repository.ReplaceArticleStocks(models.NewsArticleType, links)
+}
+
+func (l *Linker) LinkMilestones(items []models.Milestone) error {
+	links := map[uint][]models.ArticleStock{}
+	for _, item := range items {
+		links[item.ID] = articleStocks(l.Match(item.Title))
+	}
+	return repository.ReplaceArticleStocks(models.MilestoneType, links)
+}
+
+// RelinkAll links every saved article and milestone again, e.g. after the master data changed,
+// and returns how many of each there are
+func RelinkAll() (articles, milestones int, err error) {
+	l, err := Load()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	newsArticles, err := repository.GetAllNewsArticles(db.DB, "")
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := l.LinkNewsArticles(newsArticles); err != nil {
+		return 0, 0, err
+	}
+
+	items, err := repository.GetAllMilestones()
+	if err != nil {
+		return len(newsArticles), 0, err
+	}
+	if err := l.LinkMilestones(items); err != nil {
+		return len(newsArticles), 0, err
+	}
+	return len(newsArticles), len(items), nil
+}
+
+// Links of an article to the matches
+func articleStocks(matches []Match) []models.ArticleStock {
+	links := make([]models.ArticleStock, len(matches))
+	for i, m := range matches {
+		links[i] = models.ArticleStock{StockCode: m.StockCode, StockName: m.StockName, MatchedName: m.Name}
+	}
+	return links
+}
@@ -0,0 +1,34 @@
+package fixtureserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+)
+
+// New starts a local server answering with the pages saved under dir.
+// A request for /stock/4385 is served from stock/4385.html,
+// /stock/4385/news?page=2 from stock/4385/news_page2.html and / from index.html.
+// Paths without a saved page answer 404, like a missing page on the live site.
+func New(dir string) *httptest.Server {
+	return httptest.NewServer(Handler(dir))
+}
+
+// Handler serves the pages saved under dir
+func Handler(dir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Trim(r.URL.Path, "/")
+		if name == "" {
+			name = "index"
+		}
+		if page := r.URL.Query().Get("page"); page != "" {
+			name += "_page" + page
+		}
+
+		// Clean the path so that a request cannot leave dir
+		path := filepath.Join(dir, filepath.FromSlash(filepath.Clean("/"+name))+".html")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		http.ServeFile(w, r, path)
+	})
+}
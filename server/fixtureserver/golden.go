@@ -0,0 +1,40 @@
+package fixtureserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Golden compares the JSON of a scraper result with the golden file at path, or rewrites the file when update is set.
+// The addresses of the local servers are replaced by hosts with the live ones, so that the files do not depend on the port.
+func Golden(t testing.TB, path string, result any, hosts *strings.Replacer, update bool) {
+	t.Helper()
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []byte(hosts.Replace(string(data)) + "\n")
+
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("result differs from %s\n--- want\n%s--- got\n%s", path, want, got)
+	}
+}
@@ -8,6 +8,9 @@ require (
 	github.com/PuerkitoBio/goquery v1.10.1
 	github.com/gocolly/colly/v2 v2.1.0
 	github.com/labstack/echo/v4 v4.13.3
+	golang.org/x/net v0.34.0
+	golang.org/x/text v0.21.0
+	golang.org/x/time v0.8.0
 	gorm.io/driver/sqlite v1.5.7
 	gorm.io/gorm v1.25.12
 )
@@ -32,10 +35,7 @@ require (
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasttemplate v1.2.2 // indirect
 	golang.org/x/crypto v0.32.0 // indirect
-	golang.org/x/net v0.34.0 // indirect
 	golang.org/x/sys v0.29.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
-	golang.org/x/time v0.8.0 // indirect
 	google.golang.org/appengine v1.6.6 // indirect
 	google.golang.org/protobuf v1.24.0 // indirect
 )
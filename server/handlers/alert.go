@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"server/db"
+	"server/models"
+	"server/repository"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetAlertRules(c echo.Context) error {
+	// Optional filter by stock code
+	code := 0
+	if v := c.QueryParam("code"); v != "" {
+		var err error
+		if code, err = strconv.Atoi(v); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid stock code"})
+		}
+	}
+
+	rules, err := repository.GetAlertRules(code)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch alert rules"})
+	}
+	return c.JSON(http.StatusOK, rules)
+}
+
+func CreateAlertRule(c echo.Context) error {
+	rule := &models.AlertRule{Enabled: true, DeadlineDays: 1}
+	if err := c.Bind(rule); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	rule.ID = 0
+	rule.LastTriggeredAt = nil
+
+	if err := rule.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	var stock models.Stock
+	if err := db.DB.Where("stock_code = ?", rule.StockCode).First(&stock).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Stock not found"})
+	}
+
+	if err := repository.CreateAlertRule(rule); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create alert rule"})
+	}
+	return c.JSON(http.StatusCreated, rule)
+}
+
+func UpdateAlertRule(c echo.Context) error {
+	rule, err := repository.GetAlertRuleByID(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Alert rule not found"})
+	}
+
+	// Stock code and trigger history are kept
+	updated := &models.AlertRule{
+		Condition:    rule.Condition,
+		Threshold:    rule.Threshold,
+		DeadlineDays: rule.DeadlineDays,
+		Enabled:      rule.Enabled,
+	}
+	if err := c.Bind(updated); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	if err := updated.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	rule.Condition = updated.Condition
+	rule.Threshold = updated.Threshold
+	rule.DeadlineDays = updated.DeadlineDays
+	rule.Enabled = updated.Enabled
+
+	if err := repository.UpdateAlertRule(rule); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update alert rule"})
+	}
+	return c.JSON(http.StatusOK, rule)
+}
+
+func DeleteAlertRule(c echo.Context) error {
+	if _, err := repository.GetAlertRuleByID(c.Param("id")); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Alert rule not found"})
+	}
+	if err := repository.DeleteAlertRule(c.Param("id")); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete alert rule"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Alert rule deleted successfully"})
+}
@@ -1,19 +1,44 @@
 package handlers
 
 import (
+	"log"
 	"net/http"
+	"server/entitylink"
 	"server/models"
 	"server/repository"
 
 	"github.com/labstack/echo/v4"
 )
 
+// Milestone with the stocks it concerns
+type milestoneItem struct {
+	models.Milestone
+	Stocks []models.ArticleStock `json:"stocks"`
+}
+
 func GetMilestones(c echo.Context) error {
 	items, err := repository.GetAllMilestones()
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch milestone list"})
 	}
-	return c.JSON(http.StatusOK, items)
+
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	stocks, err := repository.GetArticleStocks(models.MilestoneType, ids)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch milestone list"})
+	}
+
+	response := make([]milestoneItem, len(items))
+	for i, item := range items {
+		response[i] = milestoneItem{Milestone: item, Stocks: stocks[item.ID]}
+		if response[i].Stocks == nil {
+			response[i].Stocks = []models.ArticleStock{}
+		}
+	}
+	return c.JSON(http.StatusOK, response)
 }
 
 func AddMilestone(c echo.Context) error {
@@ -25,6 +50,9 @@ func AddMilestone(c echo.Context) error {
 	if err := repository.CreateMilestone(item); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save item"})
 	}
+	if err := entitylink.LinkMilestones([]models.Milestone{*item}); err != nil {
+		log.Println("Failed to link milestone to stocks:", err)
+	}
 
 	return c.JSON(http.StatusCreated, item)
 }
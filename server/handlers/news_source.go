@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"server/models"
+	"server/repository"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetNewsSources(c echo.Context) error {
+	sources, err := repository.GetNewsSources()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch news sources"})
+	}
+	return c.JSON(http.StatusOK, sources)
+}
+
+func CreateNewsSource(c echo.Context) error {
+	source := &models.NewsSource{Enabled: true}
+	if err := c.Bind(source); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	source.ID = 0
+
+	if err := source.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if err := repository.CreateNewsSource(source); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create news source"})
+	}
+	return c.JSON(http.StatusCreated, source)
+}
+
+func UpdateNewsSource(c echo.Context) error {
+	source, err := repository.GetNewsSourceByID(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "News source not found"})
+	}
+
+	// The name is kept, as the saved articles refer to their source by it
+	updated := &models.NewsSource{
+		Name:    source.Name,
+		Kind:    source.Kind,
+		URL:     source.URL,
+		Enabled: source.Enabled,
+	}
+	if err := c.Bind(updated); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	updated.Name = source.Name
+	if err := updated.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	source.Kind = updated.Kind
+	source.URL = updated.URL
+	source.Enabled = updated.Enabled
+
+	if err := repository.UpdateNewsSource(source); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update news source"})
+	}
+	return c.JSON(http.StatusOK, source)
+}
+
+func DeleteNewsSource(c echo.Context) error {
+	if _, err := repository.GetNewsSourceByID(c.Param("id")); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "News source not found"})
+	}
+	if err := repository.DeleteNewsSource(c.Param("id")); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete news source"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "News source deleted successfully"})
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"server/db"
+	"server/models"
+	"server/portfolio"
+	"server/repository"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Holding valued at the latest cached quote
+type holdingView struct {
+	models.Holding
+	LastPrice     *float64   `json:"last_price"`
+	QuoteDate     *time.Time `json:"quote_date"`
+	MarketValue   float64    `json:"market_value"`
+	UnrealizedPnL float64    `json:"unrealized_pnl"`
+}
+
+// Portfolio with its holdings and totals
+type portfolioView struct {
+	models.Portfolio
+	Holdings      []holdingView `json:"holdings"`
+	CostBasis     float64       `json:"cost_basis"`
+	MarketValue   float64       `json:"market_value"`
+	UnrealizedPnL float64       `json:"unrealized_pnl"`
+	RealizedPnL   float64       `json:"realized_pnl"`
+}
+
+// Value the holdings of a portfolio with the latest quotes
+func valueHoldings(item *models.Portfolio) (*portfolioView, error) {
+	holdings, err := repository.GetHoldings(item.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &portfolioView{Portfolio: *item, Holdings: make([]holdingView, 0, len(holdings))}
+	for _, holding := range holdings {
+		hv := holdingView{Holding: holding}
+		if holding.Quantity > 0 {
+			quote, err := repository.GetLatestStockQuote(holding.StockCode)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			if quote != nil {
+				position := portfolio.Position{Quantity: holding.Quantity, CostBasis: holding.CostBasis}
+				hv.LastPrice = &quote.StockPrice
+				hv.QuoteDate = &quote.TradingDate
+				hv.MarketValue, hv.UnrealizedPnL = portfolio.Unrealized(&position, quote.StockPrice)
+			}
+		}
+
+		view.CostBasis += holding.CostBasis
+		view.RealizedPnL += holding.RealizedPnL
+		view.MarketValue += hv.MarketValue
+		view.UnrealizedPnL += hv.UnrealizedPnL
+		view.Holdings = append(view.Holdings, hv)
+	}
+	return view, nil
+}
+
+func GetPortfolios(c echo.Context) error {
+	items, err := repository.GetAllPortfolios()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch portfolios"})
+	}
+	return c.JSON(http.StatusOK, items)
+}
+
+func GetPortfolio(c echo.Context) error {
+	item, err := repository.GetPortfolioByID(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Portfolio not found"})
+	}
+
+	view, err := valueHoldings(item)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to value holdings"})
+	}
+	return c.JSON(http.StatusOK, view)
+}
+
+func CreatePortfolio(c echo.Context) error {
+	item := new(models.Portfolio)
+	if err := c.Bind(item); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	if item.Name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name is required"})
+	}
+
+	if err := repository.CreatePortfolio(item); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create portfolio"})
+	}
+	return c.JSON(http.StatusCreated, item)
+}
+
+func UpdatePortfolio(c echo.Context) error {
+	item, err := repository.GetPortfolioByID(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Portfolio not found"})
+	}
+
+	updated := new(models.Portfolio)
+	if err := c.Bind(updated); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	if updated.Name == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Name is required"})
+	}
+
+	item.Name = updated.Name
+	item.Description = updated.Description
+
+	if err := repository.UpdatePortfolio(item); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update portfolio"})
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+func DeletePortfolio(c echo.Context) error {
+	item, err := repository.GetPortfolioByID(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Portfolio not found"})
+	}
+	if err := repository.DeletePortfolio(item.ID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete portfolio"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Portfolio deleted successfully"})
+}
+
+func GetHoldings(c echo.Context) error {
+	item, err := repository.GetPortfolioByID(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Portfolio not found"})
+	}
+
+	view, err := valueHoldings(item)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to value holdings"})
+	}
+	return c.JSON(http.StatusOK, view.Holdings)
+}
+
+func GetTrades(c echo.Context) error {
+	item, err := repository.GetPortfolioByID(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Portfolio not found"})
+	}
+
+	trades, err := repository.GetTrades(item.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch trades"})
+	}
+	return c.JSON(http.StatusOK, trades)
+}
+
+func CreateTrade(c echo.Context) error {
+	item, err := repository.GetPortfolioByID(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Portfolio not found"})
+	}
+
+	trade := new(models.Trade)
+	if err := c.Bind(trade); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	trade.ID = 0
+	trade.PortfolioID = item.ID
+
+	// Quantity is traded in units of the stock
+	var stock models.Stock
+	if err := db.DB.Where("stock_code = ?", trade.StockCode).First(&stock).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Stock not found"})
+	}
+	if err := trade.Validate(stock.UnitShares); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	if err := repository.CreateTrade(trade); err != nil {
+		var oversold *portfolio.ErrOversold
+		if errors.As(err, &oversold) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to create trade"})
+	}
+	return c.JSON(http.StatusCreated, trade)
+}
+
+func DeleteTrade(c echo.Context) error {
+	tradeID, err := strconv.ParseUint(c.Param("tradeId"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid trade ID"})
+	}
+	item, err := repository.GetPortfolioByID(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Portfolio not found"})
+	}
+
+	if err := repository.DeleteTrade(item.ID, uint(tradeID)); err != nil {
+		var oversold *portfolio.ErrOversold
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Trade not found"})
+		case errors.As(err, &oversold):
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete trade"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Trade deleted successfully"})
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"server/repository"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Page size of the screener
+const (
+	defaultScreenLimit = 50
+	maxScreenLimit     = 500
+)
+
+// Page of the screened stocks
+type screenResult struct {
+	Total  int64                      `json:"total"`
+	Limit  int                        `json:"limit"`
+	Offset int                        `json:"offset"`
+	Stocks []repository.ScreenedStock `json:"stocks"`
+}
+
+func ScreenStocks(c echo.Context) error {
+	filter := repository.StockScreen{Limit: defaultScreenLimit}
+	filter.Industries = SplitQueryParam(c, "industry")
+	filter.Markets = SplitQueryParam(c, "market")
+
+	// Numeric filters; a missing parameter leaves the filter off
+	ints := []struct {
+		name  string
+		value *int
+	}{
+		{"settlement_month", &filter.SettlementMonth},
+		{"salary_min", &filter.SalaryMin},
+		{"salary_max", &filter.SalaryMax},
+		{"employees_min", &filter.EmployeesMin},
+		{"employees_max", &filter.EmployeesMax},
+		{"limit", &filter.Limit},
+		{"offset", &filter.Offset},
+	}
+	for _, p := range ints {
+		if v := c.QueryParam(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid " + p.name})
+			}
+			*p.value = n
+		}
+	}
+	int64s := []struct {
+		name  string
+		value *int64
+	}{
+		{"capital_min", &filter.CapitalMin},
+		{"capital_max", &filter.CapitalMax},
+	}
+	for _, p := range int64s {
+		if v := c.QueryParam(p.name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid " + p.name})
+			}
+			*p.value = n
+		}
+	}
+	floats := []struct {
+		name  string
+		value *float64
+	}{
+		{"per_min", &filter.PERMin},
+		{"per_max", &filter.PERMax},
+		{"pbr_min", &filter.PBRMin},
+		{"pbr_max", &filter.PBRMax},
+	}
+	for _, p := range floats {
+		if v := c.QueryParam(p.name); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil || n < 0 {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid " + p.name})
+			}
+			*p.value = n
+		}
+	}
+
+	if filter.SettlementMonth > 12 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid settlement_month"})
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultScreenLimit
+	} else if filter.Limit > maxScreenLimit {
+		filter.Limit = maxScreenLimit
+	}
+	if v := c.QueryParam("include_delisted"); v != "" {
+		include, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid include_delisted"})
+		}
+		filter.IncludeDelisted = include
+	}
+
+	// Sort key, descending with a leading "-" (e.g. "-salary")
+	if sort := c.QueryParam("sort"); sort != "" {
+		filter.Desc = strings.HasPrefix(sort, "-")
+		filter.Sort = strings.TrimPrefix(sort, "-")
+		if _, ok := repository.ScreenSortKeys[filter.Sort]; !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid sort"})
+		}
+	}
+
+	stocks, total, err := repository.ScreenStocks(filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to screen stocks"})
+	}
+	if stocks == nil {
+		stocks = []repository.ScreenedStock{}
+	}
+
+	return c.JSON(http.StatusOK, screenResult{Total: total, Limit: filter.Limit, Offset: filter.Offset, Stocks: stocks})
+}
+
+// SplitQueryParam returns the values of a parameter given repeatedly or comma separated
+func SplitQueryParam(c echo.Context, name string) []string {
+	var values []string
+	for _, param := range c.QueryParams()[name] {
+		for _, v := range strings.Split(param, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"server/models"
+	"server/repository"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Number of search results
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+func SearchStocks(c echo.Context) error {
+	query := strings.TrimSpace(c.QueryParam("q"))
+	if query == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Search query is required"})
+	}
+
+	limit := defaultSearchLimit
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	stocks, err := repository.SearchStocks(query, limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to search stocks"})
+	}
+	if stocks == nil {
+		stocks = []models.Stock{}
+	}
+	return c.JSON(http.StatusOK, stocks)
+}
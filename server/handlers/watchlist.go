@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"server/db"
+	"server/models"
+	"server/repository"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Watchlist entry with the last cached quote
+type watchlistEntry struct {
+	models.WatchlistItem
+	Quote *models.StockQuote `json:"quote"`
+}
+
+func GetWatchlist(c echo.Context) error {
+	items, err := repository.GetAllWatchlistItems()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch watchlist"})
+	}
+
+	entries := make([]watchlistEntry, 0, len(items))
+	for _, item := range items {
+		entry := watchlistEntry{WatchlistItem: item}
+		quote, err := repository.GetLatestStockQuote(item.StockCode)
+		if err == nil {
+			entry.Quote = quote
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch quotes"})
+		}
+		entries = append(entries, entry)
+	}
+	return c.JSON(http.StatusOK, entries)
+}
+
+func AddWatchlistItem(c echo.Context) error {
+	item := new(models.WatchlistItem)
+	if err := c.Bind(item); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+
+	// Only listed codes can be watched
+	if err := db.DB.Where("stock_code = ?", item.StockCode).First(&item.Stock).Error; err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Stock not found"})
+	}
+
+	created, err := repository.CreateWatchlistItem(item)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to add item"})
+	}
+	if !created {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "Stock is already watched"})
+	}
+
+	return c.JSON(http.StatusCreated, item)
+}
+
+// UpdateWatchlistItem changes the note and the sort order; the stock of an item is fixed
+func UpdateWatchlistItem(c echo.Context) error {
+	code, err := strconv.Atoi(c.Param("code"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid stock code"})
+	}
+	item, err := repository.GetWatchlistItem(code)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Item not found"})
+	}
+
+	updated := &models.WatchlistItem{Note: item.Note, SortOrder: item.SortOrder}
+	if err := c.Bind(updated); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid input"})
+	}
+	item.Note = updated.Note
+	item.SortOrder = updated.SortOrder
+
+	if err := repository.UpdateWatchlistItem(item); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to update item"})
+	}
+	return c.JSON(http.StatusOK, item)
+}
+
+func DeleteWatchlistItem(c echo.Context) error {
+	code, err := strconv.Atoi(c.Param("code"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid stock code"})
+	}
+	if err := repository.DeleteWatchlistItem(code); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to delete item"})
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "Deleted successfully"})
+}
@@ -0,0 +1,131 @@
+// Package jpdate parses the dates shown on Japanese news pages into times in JST.
+package jpdate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// JST is the time zone of the dates
+var JST = time.FixedZone("JST", 9*60*60)
+
+// Layouts of the dates with the year
+var layouts = []string{
+	"2006/1/2 15:04:05", "2006/1/2 15:04", "2006/1/2",
+	"2006-1-2 15:04:05", "2006-1-2 15:04", "2006-1-2",
+	"2006.1.2 15:04", "2006.1.2",
+	"2006年1月2日 15:04", "2006年1月2日 15時4分", "2006年1月2日",
+}
+
+// Layouts of the dates without the year
+var shortLayouts = []string{
+	"1/2 15:04", "1/2", "1月2日 15:04", "1月2日 15時4分", "1月2日",
+}
+
+// Layouts of a time of the day
+var timeLayouts = []string{"15:04:05", "15:04", "15時4分"}
+
+// Days named relative to today, the longest first
+var namedDays = []struct {
+	name string
+	days int
+}{
+	{"一昨日", 2}, {"おととい", 2}, {"昨日", 1}, {"きのう", 1}, {"今日", 0}, {"本日", 0}, {"きょう", 0},
+}
+
+// Elapsed time, e.g. 5分前 or 3時間前
+var agoPattern = regexp.MustCompile(`^(\d+)\s*(秒|分|時間|日|週間|週|[ヶかカケヵ]月|年)前$`)
+
+// Parse parses a date on a Japanese page in JST, taking the dates relative to now:
+//
+//	2025/02/06 15:00, 2025-02-06, 2025年2月6日 15時00分  the date with the year
+//	02/06 15:00, 2月6日                               the latest such date up to a day after now
+//	15:00                                             today
+//	今日 15:00, 昨日, 一昨日 9:30                       a named day
+//	たった今, 5分前, 3時間前, 2日前, 1ヶ月前             the time elapsed
+//
+// Full-width digits and spaces are accepted. A date without a time is at midnight.
+func Parse(s string, now time.Time) (time.Time, error) {
+	value := strings.Join(strings.Fields(norm.NFKC.String(s)), " ")
+	now = now.In(JST)
+
+	switch value {
+	case "":
+		return time.Time{}, fmt.Errorf("empty date")
+	case "たった今", "今", "いま":
+		return now, nil
+	}
+
+	if m := agoPattern.FindStringSubmatch(value); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch unit := m[2]; unit {
+		case "秒":
+			return now.Add(-time.Duration(n) * time.Second), nil
+		case "分":
+			return now.Add(-time.Duration(n) * time.Minute), nil
+		case "時間":
+			return now.Add(-time.Duration(n) * time.Hour), nil
+		case "日":
+			return now.AddDate(0, 0, -n), nil
+		case "週間", "週":
+			return now.AddDate(0, 0, -7*n), nil
+		case "年":
+			return now.AddDate(-n, 0, 0), nil
+		default: // ヶ月
+			return now.AddDate(0, -n, 0), nil
+		}
+	}
+
+	for _, named := range namedDays {
+		if rest, ok := strings.CutPrefix(value, named.name); ok {
+			day := now.AddDate(0, 0, -named.days)
+			if rest = strings.TrimSpace(rest); rest == "" {
+				return atTime(day, time.Time{}), nil
+			}
+			if t, ok := parseLayouts(timeLayouts, rest); ok {
+				return atTime(day, t), nil
+			}
+			return time.Time{}, fmt.Errorf("unrecognized date %q", s)
+		}
+	}
+
+	if t, ok := parseLayouts(layouts, value); ok {
+		return t, nil
+	}
+
+	if t, ok := parseLayouts(shortLayouts, value); ok {
+		// Take the latest year in which the date exists and is at most a day ahead of now,
+		// which is the year before for 12/31 on New Year's Day, or a leap year for 2/29
+		for year := now.Year(); year > now.Year()-8; year-- {
+			date := time.Date(year, t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, JST)
+			if date.Day() == t.Day() && !date.After(now.Add(24*time.Hour)) {
+				return date, nil
+			}
+		}
+	}
+
+	if t, ok := parseLayouts(timeLayouts, value); ok {
+		return atTime(now, t), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date %q", s)
+}
+
+func parseLayouts(layouts []string, value string) (time.Time, bool) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, JST); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// The day at the time of the day of t
+func atTime(day, t time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, JST)
+}
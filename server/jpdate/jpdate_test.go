@@ -0,0 +1,85 @@
+package jpdate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// Fixed now: 2026-03-10 12:00 JST, in a year without 2/29
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, JST)
+	newYear := time.Date(2026, 1, 1, 9, 0, 0, 0, JST)
+	date := func(y int, m time.Month, d, h, min int) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, JST)
+	}
+
+	tests := []struct {
+		in   string
+		now  time.Time
+		want time.Time
+	}{
+		// With the year
+		{"2025/02/06 15:00", now, date(2025, 2, 6, 15, 0)},
+		{"2025-02-06", now, date(2025, 2, 6, 0, 0)},
+		{"2025.2.6 9:05", now, date(2025, 2, 6, 9, 5)},
+		{"2025年2月6日 15時00分", now, date(2025, 2, 6, 15, 0)},
+		{"２０２５／０２／０６　１５：００", now, date(2025, 2, 6, 15, 0)},
+		// Without the year: the latest such date up to a day ahead
+		{"02/06 15:00", now, date(2026, 2, 6, 15, 0)},
+		{"3月11日", now, date(2026, 3, 11, 0, 0)},
+		{"03/12 10:00", now, date(2025, 3, 12, 10, 0)},
+		{"12/31 23:59", newYear, date(2025, 12, 31, 23, 59)},
+		{"01/01 08:00", newYear, date(2026, 1, 1, 8, 0)},
+		{"02/29", now, date(2024, 2, 29, 0, 0)},
+		{"０２／０６", now, date(2026, 2, 6, 0, 0)},
+		// A time of today
+		{"15:00", now, date(2026, 3, 10, 15, 0)},
+		// Named days
+		{"今日 9:30", now, date(2026, 3, 10, 9, 30)},
+		{"本日", now, date(2026, 3, 10, 0, 0)},
+		{"昨日", now, date(2026, 3, 9, 0, 0)},
+		{"一昨日 9:30", now, date(2026, 3, 8, 9, 30)},
+		{"昨日 18時5分", now, date(2026, 3, 9, 18, 5)},
+		// Elapsed time
+		{"たった今", now, now},
+		{"30秒前", now, now.Add(-30 * time.Second)},
+		{"5分前", now, now.Add(-5 * time.Minute)},
+		{"３時間前", now, now.Add(-3 * time.Hour)},
+		{"2日前", now, date(2026, 3, 8, 12, 0)},
+		{"1週間前", now, date(2026, 3, 3, 12, 0)},
+		{"1ヶ月前", now, date(2026, 2, 10, 12, 0)},
+		{"2か月前", now, date(2026, 1, 10, 12, 0)},
+		{"1年前", now, date(2025, 3, 10, 12, 0)},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, tt.now)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseNowInOtherZone(t *testing.T) {
+	// 2026-03-09 20:00 UTC is already 3/10 in JST
+	now := time.Date(2026, 3, 9, 20, 0, 0, 0, time.UTC)
+	got, err := Parse("今日", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 10, 0, 0, 0, 0, JST); !got.Equal(want) {
+		t.Errorf("Parse(今日) = %v, want %v", got, want)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, JST)
+	for _, in := range []string{"", "   ", "---", "02/30", "13/01", "明日", "昨日 夜", "2025/13/01"} {
+		if got, err := Parse(in, now); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", in, got)
+		}
+	}
+}
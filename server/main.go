@@ -1,10 +1,19 @@
 package main
 
 import (
+	"server/config"
 	"server/db"
+	"server/marketdata"
+	"server/news"
+	"server/repository"
 	"server/routes"
+	"server/scheduler"
 
+	"context"
+	"log"
 	"net/http"
+	"os"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
@@ -15,13 +24,34 @@ func main() {
 	e := echo.New()
 
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"http://localhost:3000"},
-		AllowMethods: []string{echo.GET, echo.POST, echo.PUT, echo.DELETE},
+		AllowOrigins:  []string{"http://localhost:3000"},
+		AllowMethods:  []string{echo.GET, echo.POST, echo.PUT, echo.DELETE},
+		ExposeHeaders: []string{"X-Total-Count"},
 	}))
 
 	// Init DB
 	DB := db.InitDB()
 
+	// Full-text index of the stock master data
+	if err := repository.InitStockSearchIndex(); err != nil {
+		log.Println("WARNING: stock search index unavailable, the search falls back to LIKE (build with -tags sqlite_fts5):", err)
+	}
+
+	// Configured news sources
+	if err := repository.InitNewsSources(news.DefaultSources()); err != nil {
+		log.Println("Failed to configure the news sources:", err)
+	}
+
+	// Market data source
+	provider, err := marketdata.New(config.Getenv("MARKET_DATA_PROVIDER", "minkabu,yahoo"), config.Getenv("MARKET_DATA_FIXTURE_DIR", "fixtures"))
+	if err != nil {
+		log.Fatal("Invalid market data provider:", err)
+	}
+	log.Println("Market data provider:", provider.Name())
+
+	// Keep the outcome of every scrape for the health check
+	marketdata.SetRunRecorder(routes.RecordScrapeRun)
+
 	// Root Endpoint
 	e.GET("/hello", func(c echo.Context) error {
 		return c.String(http.StatusOK, "Welcome!")
@@ -36,11 +66,35 @@ func main() {
 	api := e.Group("/api")
 	routes.RegisterEventRoutes(api)
 	routes.RegisterUserRoutes(api)
-	routes.RegisterStockRoutes(api)
-	routes.RegisterBloombergRoutes(api, DB)
+	routes.RegisterStockRoutes(api, provider)
+	routes.RegisterNewsRoutes(api, DB)
 	routes.RegisterMilestoneRoutes(api)
 	routes.RegisterImportStockMasterDataFromCSV(api)
+	routes.RegisterWatchlistRoutes(api)
+	routes.RegisterPortfolioRoutes(api)
+	routes.RegisterAlertRoutes(api)
+	routes.RegisterAdminRoutes(api)
+
+	// Refresh the watchlist quotes in the background
+	if interval := quoteRefreshInterval(); interval > 0 {
+		go scheduler.Run(context.Background(), interval, routes.RefreshWatchlistQuotes(provider))
+	}
 
 	// Awake server
 	e.Logger.Fatal(e.Start(":8080"))
 }
+
+// Interval of the watchlist refresher from QUOTE_REFRESH_INTERVAL (e.g. "10m", "0" to disable)
+func quoteRefreshInterval() time.Duration {
+	value := os.Getenv("QUOTE_REFRESH_INTERVAL")
+	if value == "" {
+		return 15 * time.Minute
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid QUOTE_REFRESH_INTERVAL %q, refresher disabled", value)
+		return 0
+	}
+	return interval
+}
@@ -0,0 +1,39 @@
+package marketdata
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// Deadline of a single page request
+const requestTimeout = 15 * time.Second
+
+// contextTransport binds every request of a collector to ctx,
+// so that a cancelled or expired context aborts the crawl in flight.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// BindContext ties the requests of the collector to ctx and applies the per-request timeout
+func BindContext(ctx context.Context, c *colly.Collector) {
+	c.WithTransport(contextTransport{ctx: ctx, base: http.DefaultTransport})
+	c.SetRequestTimeout(requestTimeout)
+}
+
+// Hostname returns the host of a base URL, for the allowed domains of a collector
+func Hostname(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return baseURL
+	}
+	return u.Hostname()
+}
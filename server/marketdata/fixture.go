@@ -0,0 +1,72 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Fixture serves data saved as JSON files, for offline runs.
+// The files are laid out as <dir>/<code>/quote.json, news.json and profile.json.
+type Fixture struct {
+	Dir string
+}
+
+func NewFixture(dir string) *Fixture {
+	return &Fixture{Dir: dir}
+}
+
+func (f *Fixture) Name() string {
+	return "fixture"
+}
+
+func (f *Fixture) Quote(ctx context.Context, code string) (Quote, error) {
+	var quote Quote
+	err := f.load(code, "quote.json", &quote)
+	return quote, err
+}
+
+// News returns the saved articles of the sources up to the first known one, as a single page; the window and pages do not apply to the saved list
+func (f *Fixture) News(ctx context.Context, code string, opts NewsOptions) ([]Article, error) {
+	var saved []Article
+	if err := f.load(code, "news.json", &saved); err != nil {
+		return nil, err
+	}
+
+	sources := opts.sourceSet()
+	articles := []Article{}
+	for _, article := range saved {
+		if opts.Known != nil && opts.Known(article.Link) {
+			break
+		}
+		if sources == nil || sources[article.Source] {
+			articles = append(articles, article)
+		}
+	}
+	if opts.OnPage != nil {
+		opts.OnPage(1, articles)
+	}
+	return articles, nil
+}
+
+func (f *Fixture) Profile(ctx context.Context, code string) (Profile, error) {
+	var profile Profile
+	err := f.load(code, "profile.json", &profile)
+	return profile, err
+}
+
+func (f *Fixture) load(code, name string, v any) error {
+	path := filepath.Join(f.Dir, filepath.Base(code), name)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s: %w", path, ErrNoData)
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
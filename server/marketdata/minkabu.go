@@ -0,0 +1,283 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"server/normalize"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+)
+
+// MinkabuBaseURL is the address of minkabu.jp
+const MinkabuBaseURL = "https://minkabu.jp"
+
+// Minkabu scrapes minkabu.jp, or a site with the same markup at BaseURL
+type Minkabu struct {
+	BaseURL   string
+	NewsPager Pager // Pages of the news read at the same time, minkabuNewsPager if zero
+}
+
+func NewMinkabu(baseURL string) *Minkabu {
+	return &Minkabu{BaseURL: baseURL}
+}
+
+func (m *Minkabu) Name() string {
+	return "minkabu"
+}
+
+func (m *Minkabu) newCollector(ctx context.Context) *colly.Collector {
+	c := colly.NewCollector(
+		colly.AllowedDomains(Hostname(m.BaseURL)),
+	)
+	BindContext(ctx, c)
+
+	c.Limit(&colly.LimitRule{
+		DomainGlob:  "*minkabu.jp",
+		Delay:       1 * time.Second,
+		RandomDelay: 1 * time.Second,
+	})
+
+	return c
+}
+
+func (m *Minkabu) Quote(ctx context.Context, code string) (Quote, error) {
+	c := m.newCollector(ctx)
+	pages := TrackPages(c)
+
+	// Initialize variables
+	var quote Quote
+	quote.Code = code
+	stopHigh := false
+
+	// Extract stock information from Minkabu
+	c.OnHTML(".stock_price", func(e *colly.HTMLElement) {
+		price, err := normalize.Price(strings.Split(e.Text, "円")[0])
+		if err == nil {
+			quote.StockPrice = price
+			pages.Found("stock_price")
+		}
+	})
+
+	c.OnHTML("table.md_table tbody tr", func(e *colly.HTMLElement) {
+		label := strings.TrimSpace(e.ChildText("th"))
+		value := strings.TrimSpace(e.ChildText("td"))
+
+		// Identify the data based on the label
+		switch label {
+		case "時価総額": // Market capitalization
+			if marketCap, err := normalize.Yen(value); err == nil {
+				quote.MarketCap = marketCap
+				pages.Found("market_cap")
+			}
+		case "発行済株数": // Issued shares
+			if issuedShares, err := normalize.Shares(value); err == nil {
+				quote.IssuedShares = issuedShares
+				pages.Found("issued_shares")
+			}
+		}
+	})
+
+	c.OnHTML("table.md_table.theme_light tr.ly_vamd", func(e *colly.HTMLElement) {
+		label := strings.TrimSpace(e.ChildText("th"))
+		value := strings.TrimSpace(e.ChildText("td"))
+
+		if strings.Contains(label, "前日終値") { // Match "前日終値"
+			if prevClose, err := normalize.Price(value); err == nil {
+				quote.PrevClose = prevClose
+				pages.Found("prev_close")
+			}
+		}
+	})
+
+	// Extract price change and check if "STOP高" exists
+	c.OnHTML(".md_stockBoard_stockTable", func(e *colly.HTMLElement) {
+		change, percent, err := normalize.Change(e.ChildText(".stock_price_diff"))
+		if err == nil {
+			quote.PriceChange = change
+			quote.PriceChangePercent = percent
+			pages.Found("price_change")
+		}
+		if e.ChildText(".hi") == "STOP高" {
+			stopHigh = true
+		}
+	})
+
+	// Variables for financial data
+	var perSum, pbrSum float64
+	var count float64
+
+	// Extract PER and PBR values
+	c.OnHTML("table.md_table tr", func(e *colly.HTMLElement) {
+		cells := e.DOM.Find("td").Map(func(i int, s *goquery.Selection) string {
+			return strings.TrimSpace(s.Text())
+		})
+
+		if len(cells) >= 4 {
+			per, err1 := strconv.ParseFloat(strings.ReplaceAll(cells[2], ",", ""), 64)
+			pbr, err2 := strconv.ParseFloat(strings.ReplaceAll(cells[3], ",", ""), 64)
+			if err1 == nil && err2 == nil {
+				perSum += per
+				pbrSum += pbr
+				count++
+				pages.Found("per_pbr")
+			}
+		}
+	})
+
+	c.OnScraped(func(r *colly.Response) {
+		if count > 0 {
+			quote.AveragePER = math.Round((perSum/count)*100) / 100
+			quote.AveragePBR = math.Round((pbrSum/count)*100) / 100
+		} else {
+			quote.AveragePER = 0
+			quote.AveragePBR = 0
+		}
+		quote.StopHigh = stopHigh
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Failed to crawl %s: %v", r.Request.URL, err)
+	})
+
+	// Visit the stock page and the daily valuation page (Visit returns after the callbacks)
+	stockURL := fmt.Sprintf("%s/stock/%s", m.BaseURL, code)
+	if err := pages.Visit("minkabu.quote", stockURL, "stock_price", "market_cap", "issued_shares", "prev_close", "price_change"); err != nil {
+		return quote, fmt.Errorf("visit %s: %w", stockURL, err)
+	}
+	valuationURL := fmt.Sprintf("%s/stock/%s/daily_valuation", m.BaseURL, code)
+	if err := pages.Visit("minkabu.daily_valuation", valuationURL, "per_pbr"); err != nil {
+		return quote, fmt.Errorf("visit %s: %w", valuationURL, err)
+	}
+
+	if quote.StockPrice == 0 {
+		return quote, fmt.Errorf("%s: %w", stockURL, ErrNoData)
+	}
+
+	return quote, nil
+}
+
+// Pages of the news read at the same time, within the rate limit of the site
+var minkabuNewsPager = Pager{Parallelism: 3, Interval: 1 * time.Second}
+
+func (m *Minkabu) News(ctx context.Context, code string, opts NewsOptions) ([]Article, error) {
+	pager := m.NewsPager
+	if pager == (Pager{}) {
+		pager = minkabuNewsPager
+	}
+	return CollectNews(ctx, pager, opts, func(ctx context.Context, page int) (NewsPage, error) {
+		return m.newsPage(ctx, code, page)
+	})
+}
+
+// Read a news page of a stock; the page after the last one is not found
+func (m *Minkabu) newsPage(ctx context.Context, code string, page int) (NewsPage, error) {
+	var newsPage NewsPage
+
+	url := fmt.Sprintf("%s/stock/%s/news?page=%d", m.BaseURL, code, page)
+
+	c := m.newCollector(ctx)
+	pages := TrackPages(c)
+
+	c.OnHTML(".md_card_ti", func(e *colly.HTMLElement) {
+		if strings.Contains(e.Text, "ページが見つかりませんでした") {
+			newsPage.Last = true
+		}
+	})
+
+	c.OnHTML("li", func(e *colly.HTMLElement) {
+		title := strings.TrimSpace(e.ChildText(".title_box a"))
+		link := e.ChildAttr(".title_box a", "href")
+		if title == "" {
+			return
+		}
+		pages.Found("articles")
+
+		newsPage.Articles = append(newsPage.Articles, Article{
+			Title:  title,
+			Link:   e.Request.AbsoluteURL(link),
+			Source: strings.TrimSpace(e.ChildText(".fcgl")),
+			Date:   strings.TrimSpace(e.ChildText(".flex.items-center")),
+		})
+	})
+
+	if err := pages.Visit("minkabu.news", url, "articles"); err != nil {
+		// The page after the last one may answer 404
+		if page > 1 && pages.Status() == http.StatusNotFound {
+			return NewsPage{Last: true}, nil
+		}
+		log.Println("Failed to visit:", err)
+		return newsPage, fmt.Errorf("visit %s: %w", url, err)
+	}
+	return newsPage, nil
+}
+
+// Profile reads the company information from the fundamental page
+func (m *Minkabu) Profile(ctx context.Context, code string) (Profile, error) {
+	c := m.newCollector(ctx)
+	pages := TrackPages(c)
+
+	profile := Profile{Code: code}
+
+	c.OnHTML("div.md_stockBoard", func(e *colly.HTMLElement) {
+		profile.StockName = e.ChildText("h2 span.md_stockBoard_stockName")
+		if parts := strings.Split(e.ChildText("div.stock_label"), "\u00a0 \u00a0 "); len(parts) > 1 {
+			profile.MarketType = strings.TrimSpace(parts[1]) // Use full-width space
+		}
+	})
+
+	c.OnHTML("dl.md_dataList", func(e *colly.HTMLElement) {
+		e.ForEach("dt", func(_ int, dt *colly.HTMLElement) {
+			value := strings.TrimSpace(dt.DOM.Next().Text())
+			if value != "" {
+				pages.Found(dt.Text)
+			}
+
+			switch dt.Text {
+			case "社名":
+				profile.CompanyName = value
+			case "英文社名":
+				profile.EnglishCompanyName = value
+			case "業種":
+				profile.Industry = value
+			case "代表者":
+				profile.Representative = value
+			case "決算":
+				profile.SettlementMonth = value
+			case "資本金":
+				profile.Capital = value
+			case "住所":
+				profile.Address = value
+			case "電話番号(IR)":
+				profile.Phone = value
+			case "上場市場":
+				profile.ListingMarket = value
+			case "上場年月日":
+				profile.ListingDate = value
+			case "単元株数":
+				profile.UnitShares = value
+			}
+		})
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Failed to crawl %s: %v", r.Request.URL, err)
+	})
+
+	fundamentalURL := fmt.Sprintf("%s/stock/%s/fundamental", m.BaseURL, code)
+	if err := pages.Visit("minkabu.fundamental", fundamentalURL, "社名", "業種", "決算", "資本金", "上場市場", "単元株数"); err != nil {
+		return profile, fmt.Errorf("visit %s: %w", fundamentalURL, err)
+	}
+
+	if profile.CompanyName == "" {
+		return profile, fmt.Errorf("%s: %w", fundamentalURL, ErrNoData)
+	}
+
+	return profile, nil
+}
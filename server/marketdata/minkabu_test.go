@@ -0,0 +1,65 @@
+package marketdata_test
+
+import (
+	"context"
+	"flag"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"server/fixtureserver"
+	"server/marketdata"
+)
+
+// go test ./marketdata -update rewrites the golden files after a selector change
+var update = flag.Bool("update", false, "rewrite the golden files with the current results")
+
+// Code of the stock saved in the testdata
+const code = "4385"
+
+// Run the scraper against the pages saved under testdata/html and compare its result with testdata/golden
+func checkGolden(t *testing.T, golden string, hosts *strings.Replacer, run func(ctx context.Context) (any, error)) {
+	t.Helper()
+	result, err := run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtureserver.Golden(t, filepath.Join("testdata", "golden", golden), result, hosts, *update)
+}
+
+func TestMinkabu(t *testing.T) {
+	server := fixtureserver.New(filepath.Join("testdata", "html", "minkabu"))
+	defer server.Close()
+	minkabu := marketdata.NewMinkabu(server.URL)
+	hosts := strings.NewReplacer(server.URL, marketdata.MinkabuBaseURL)
+
+	tests := []struct {
+		golden string
+		run    func(ctx context.Context) (any, error)
+	}{
+		{"minkabu_quote.json", func(ctx context.Context) (any, error) { return minkabu.Quote(ctx, code) }},
+		{"minkabu_news.json", func(ctx context.Context) (any, error) { return minkabu.News(ctx, code, marketdata.NewsOptions{}) }},
+		{"minkabu_news_since_saved.json", func(ctx context.Context) (any, error) {
+			// The crawl stops at the saved article
+			saved := server.URL + "/stock/4385/news/4150128"
+			return minkabu.News(ctx, code, marketdata.NewsOptions{Known: func(link string) bool { return link == saved }})
+		}},
+		{"minkabu_news_all_sources.json", func(ctx context.Context) (any, error) {
+			return minkabu.News(ctx, code, marketdata.NewsOptions{Sources: []string{}, MaxPages: 1})
+		}},
+		{"minkabu_news_empty_pages.json", func(ctx context.Context) (any, error) {
+			// Pages 2 and 3 render without articles, so page 4 is not read
+			return minkabu.News(ctx, "6758", marketdata.NewsOptions{EmptyPages: 2})
+		}},
+		{"minkabu_news_last_page.json", func(ctx context.Context) (any, error) {
+			// Page 4 is read after two empty pages, and page 5 answers 404
+			return minkabu.News(ctx, "6758", marketdata.NewsOptions{})
+		}},
+		{"minkabu_profile.json", func(ctx context.Context) (any, error) { return minkabu.Profile(ctx, code) }},
+	}
+	for _, tt := range tests {
+		t.Run(strings.TrimSuffix(tt.golden, ".json"), func(t *testing.T) {
+			checkGolden(t, tt.golden, hosts, tt.run)
+		})
+	}
+}
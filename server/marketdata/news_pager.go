@@ -0,0 +1,135 @@
+package marketdata
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"server/jpdate"
+
+	"golang.org/x/time/rate"
+)
+
+// News pages in a row without a dated article after which the pages are taken to have ended
+const DefaultEmptyPageStreak = 3
+
+// NewsPage is a news page of a stock, with the articles of every source in the order of the page
+type NewsPage struct {
+	Articles []Article
+	Last     bool // No page follows, e.g. the page was not found
+}
+
+// Pager fetches the pages after the one being read ahead of time
+type Pager struct {
+	Parallelism int           // Pages fetched at the same time, 1 if zero
+	Interval    time.Duration // Least time between the starts of two fetches
+}
+
+// CollectNews reads the news pages from page 1 with fetch and returns the articles selected by opts in page order.
+// The pages are fetched ahead as the pager allows, and read in order until the last page, MaxPages,
+// EmptyPages pages without a dated article, an article older than the window, or a known article.
+// The pages fetched after the one that ends the crawl are discarded.
+func CollectNews(ctx context.Context, pager Pager, opts NewsOptions, fetch func(ctx context.Context, page int) (NewsPage, error)) ([]Article, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // Stops the fetches ahead
+
+	type result struct {
+		page NewsPage
+		err  error
+	}
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	if pager.Interval > 0 {
+		limiter = rate.NewLimiter(rate.Every(pager.Interval), 1)
+	}
+	results := map[int]chan result{}
+	next := 1
+	launch := func() {
+		ch := make(chan result, 1)
+		results[next] = ch
+		go func(page int) {
+			if err := limiter.Wait(ctx); err != nil {
+				ch <- result{err: err}
+				return
+			}
+			p, err := fetch(ctx, page)
+			ch <- result{page: p, err: err}
+		}(next)
+		next++
+	}
+
+	articles := []Article{}
+	sources := opts.sourceSet()
+	now := time.Now()
+	cutoff := now.Add(-opts.window())
+	emptyPages := 0
+	coverage := opts.Coverage
+	if coverage == nil {
+		coverage = &NewsCoverage{}
+	}
+	*coverage = NewsCoverage{}
+
+	for page := 1; ; page++ {
+		if opts.MaxPages > 0 && page > opts.MaxPages {
+			log.Printf("News crawl stopped after %d pages", opts.MaxPages)
+			return articles, nil
+		}
+		for next < page+max(pager.Parallelism, 1) && (opts.MaxPages == 0 || next <= opts.MaxPages) {
+			launch()
+		}
+		r := <-results[page]
+		delete(results, page)
+		if r.err != nil {
+			return articles, r.err
+		}
+
+		// The articles of the page up to the one that ends the crawl are kept
+		kept := []Article{}
+		dated, stop := false, false
+		for _, article := range r.page.Articles {
+			// The articles after a saved one were saved with it
+			if opts.Known != nil && opts.Known(article.Link) {
+				log.Printf("News crawl stopped at a saved article on page %d", page)
+				coverage.Known = true
+				stop = true
+				break
+			}
+
+			publishedAt, err := jpdate.Parse(article.Date, now)
+			if err != nil {
+				log.Printf("Skipped article: %v", err)
+				continue
+			}
+			dated = true
+			if publishedAt.Before(cutoff) {
+				log.Printf("News crawl stopped at an article of %s on page %d", publishedAt.Format("2006/01/02"), page)
+				coverage.Window = true
+				stop = true
+				break
+			}
+			if coverage.Oldest.IsZero() || publishedAt.Before(coverage.Oldest) {
+				coverage.Oldest = publishedAt
+			}
+
+			if sources == nil || sources[article.Source] {
+				kept = append(kept, article)
+			}
+		}
+		articles = append(articles, kept...)
+		if opts.OnPage != nil {
+			opts.OnPage(page, kept)
+		}
+
+		if stop || r.page.Last {
+			coverage.LastPage = !stop
+			return articles, nil
+		}
+		if !dated {
+			if emptyPages++; emptyPages >= opts.emptyPages() {
+				log.Printf("News crawl stopped after %d pages in a row without articles", emptyPages)
+				return articles, nil
+			}
+		} else {
+			emptyPages = 0
+		}
+	}
+}
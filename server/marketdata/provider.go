@@ -0,0 +1,301 @@
+// Package marketdata scrapes the quotes, company profiles and news pages of the stocks.
+//
+// The configured providers are tried in order until one succeeds, and the company profiles
+// are merged, each empty field filled from the next provider. The fixture provider serves
+// saved JSON files without network access.
+//
+// The news pages of a stock are read by CollectNews, shared by the server and the crawler.
+// The pages after the one being read are fetched ahead, Pager.Parallelism at a time with one
+// started every Pager.Interval, and read in page order so that the articles keep the order
+// of the site. Reading stops at the last page, MaxPages, a known article, an article older
+// than the window, or EmptyPages pages in a row without a dated article.
+//
+// Every page visit is passed to the RunRecorder, which the server stores for the health of the scrapers.
+package marketdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotSupported is returned by a provider for data its source does not publish
+	ErrNotSupported = errors.New("not supported by provider")
+	// ErrNoData is returned when the page was fetched but nothing could be extracted
+	ErrNoData = errors.New("no data found")
+	// ErrNotFound is returned when the site has no page for the stock
+	ErrNotFound = errors.New("page not found")
+)
+
+// MarketDataProvider is a source of quotes, news and company profiles
+type MarketDataProvider interface {
+	Name() string
+	Quote(ctx context.Context, code string) (Quote, error)
+	// News returns the articles selected by opts, in the order of the news pages
+	News(ctx context.Context, code string, opts NewsOptions) ([]Article, error)
+	Profile(ctx context.Context, code string) (Profile, error)
+}
+
+// Quote is the latest market data of a stock
+type Quote struct {
+	Code               string  `json:"code"`
+	StockPrice         float64 `json:"stock_price"`          // Yen
+	MarketCap          int64   `json:"market_cap"`           // Yen
+	IssuedShares       int64   `json:"issued_shares"`        // Shares
+	PrevClose          float64 `json:"prev_close"`           // Yen
+	PriceChange        float64 `json:"price_change"`         // Yen from the previous close
+	PriceChangePercent float64 `json:"price_change_percent"` // Percent from the previous close
+	StopHigh           bool    `json:"stop_high"`
+	AveragePER         float64 `json:"average_per"`
+	AveragePBR         float64 `json:"average_pbr"`
+}
+
+// Article is a news item or disclosure of a stock
+type Article struct {
+	Title  string `json:"title"`
+	Link   string `json:"link"`
+	Source string `json:"source"`
+	Date   string `json:"date"`
+}
+
+// Sources of the articles News returns by default
+var DefaultNewsSources = []string{"適時開示", "PR TIMES"}
+
+// Time back from now within which News returns the articles by default
+const DefaultNewsWindow = 365 * 24 * time.Hour
+
+// NewsOptions select the articles of a stock that News returns
+type NewsOptions struct {
+	Sources  []string      // Sources of the articles, e.g. 適時開示; DefaultNewsSources if nil, all of them if empty
+	Window   time.Duration // The crawl stops at an article published before this time back from now; DefaultNewsWindow if zero
+	MaxPages int           // News pages read at most, no limit if zero
+	// The crawl stops after this many pages in a row without a dated article; DefaultEmptyPageStreak if zero
+	EmptyPages int
+	// The crawl stops at the first article whose link Known reports, which is left out
+	Known func(link string) bool
+	// OnPage is called with the articles kept from each page as soon as it is read, in page order
+	OnPage func(page int, articles []Article)
+	// Coverage, if not nil, is set to how far back the crawl read
+	Coverage *NewsCoverage
+}
+
+// NewsCoverage is how far back a news crawl read the pages
+type NewsCoverage struct {
+	Oldest   time.Time // Publish time of the oldest article read, zero if none
+	Window   bool      // Stopped at an article older than the window, so every article within it was read
+	LastPage bool      // Read to the last page, so no older article exists
+	Known    bool      // Stopped at a known article, where an earlier crawl reached
+}
+
+// Set of the sources to keep, nil for all of them
+func (o NewsOptions) sourceSet() map[string]bool {
+	sources := o.Sources
+	if sources == nil {
+		sources = DefaultNewsSources
+	}
+	if len(sources) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(sources))
+	for _, source := range sources {
+		set[source] = true
+	}
+	return set
+}
+
+func (o NewsOptions) window() time.Duration {
+	if o.Window <= 0 {
+		return DefaultNewsWindow
+	}
+	return o.Window
+}
+
+func (o NewsOptions) emptyPages() int {
+	if o.EmptyPages <= 0 {
+		return DefaultEmptyPageStreak
+	}
+	return o.EmptyPages
+}
+
+// Profile is the company information of a stock.
+// Providers fill the fields their source publishes and leave the others empty.
+type Profile struct {
+	Code                  string  `json:"code"`
+	StockName             string  `json:"stock_name"`
+	MarketType            string  `json:"market_type"`
+	CompanyName           string  `json:"company_name"`
+	EnglishCompanyName    string  `json:"english_company_name"`
+	Industry              string  `json:"industry"`
+	Representative        string  `json:"representative"`
+	SettlementMonth       string  `json:"settlement_month"`
+	Capital               string  `json:"capital"`
+	Address               string  `json:"address"`
+	Phone                 string  `json:"phone"`
+	ListingMarket         string  `json:"listing_market"`
+	ListingDate           string  `json:"listing_date"`
+	UnitShares            string  `json:"unit_shares"`
+	Feature               string  `json:"feature"`
+	Business              string  `json:"business"`
+	EmployeesSolo         int     `json:"employees_solo"`
+	EmployeesConsolidated int     `json:"employees_consolidated"`
+	AverageAge            float64 `json:"average_age"`
+	AverageSalary         int     `json:"average_salary"`
+}
+
+// New builds the provider from a comma separated list of names, e.g. "minkabu,yahoo".
+// With more than one name the providers are tried in order until one succeeds,
+// and the profiles of all of them are merged.
+func New(names string, fixtureDir string) (MarketDataProvider, error) {
+	var providers []MarketDataProvider
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "minkabu":
+			providers = append(providers, NewMinkabu(MinkabuBaseURL))
+		case "yahoo":
+			providers = append(providers, NewYahoo(YahooBaseURL))
+		case "fixture":
+			providers = append(providers, NewFixture(fixtureDir))
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown market data provider: %q", name)
+		}
+	}
+
+	switch len(providers) {
+	case 0:
+		return nil, errors.New("no market data provider configured")
+	case 1:
+		return providers[0], nil
+	}
+	return Fallback(providers), nil
+}
+
+// Fallback tries each provider in order and returns the first successful result
+type Fallback []MarketDataProvider
+
+func (f Fallback) Name() string {
+	names := make([]string, len(f))
+	for i, p := range f {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (f Fallback) Quote(ctx context.Context, code string) (Quote, error) {
+	return fallback(ctx, f, func(p MarketDataProvider) (Quote, error) { return p.Quote(ctx, code) })
+}
+
+// News returns the articles of the first provider that succeeds, and only its pages reach OnPage:
+// the pages of a provider are held back until it succeeds, except those of the last provider,
+// which no other follows. The coverage is that of the provider that succeeded.
+func (f Fallback) News(ctx context.Context, code string, opts NewsOptions) ([]Article, error) {
+	onPage, coverage := opts.OnPage, opts.Coverage
+	if coverage != nil {
+		*coverage = NewsCoverage{}
+	}
+
+	i := 0
+	return fallback(ctx, f, func(p MarketDataProvider) ([]Article, error) {
+		last := i == len(f)-1
+		i++
+
+		type page struct {
+			number   int
+			articles []Article
+		}
+		var held []page
+		try := opts
+		try.Coverage = &NewsCoverage{}
+		if onPage != nil && !last {
+			try.OnPage = func(number int, articles []Article) {
+				held = append(held, page{number, articles})
+			}
+		}
+
+		articles, err := p.News(ctx, code, try)
+		if err != nil {
+			return nil, err
+		}
+		for _, page := range held {
+			onPage(page.number, page.articles)
+		}
+		if coverage != nil {
+			*coverage = *try.Coverage
+		}
+		return articles, nil
+	})
+}
+
+// Profile merges the profiles of the providers, as each site publishes only some of the fields:
+// a field left empty is filled from the next provider, which are asked until no field is empty.
+// It fails only when no provider has the profile.
+func (f Fallback) Profile(ctx context.Context, code string) (Profile, error) {
+	var merged Profile
+	found := false
+	err := ErrNotSupported
+	for _, p := range f {
+		profile, perr := p.Profile(ctx, code)
+		if perr != nil {
+			err = perr
+			// Stop when the caller has given up
+			if ctx.Err() != nil {
+				break
+			}
+			if !errors.Is(perr, ErrNotSupported) {
+				log.Printf("Provider %s failed, trying next: %v", p.Name(), perr)
+			}
+			continue
+		}
+
+		if !found {
+			merged, found = profile, true
+		}
+		if fillProfile(&merged, profile) {
+			break
+		}
+	}
+	if !found {
+		return Profile{}, err
+	}
+	return merged, nil
+}
+
+// Fill the empty fields of the profile from src, and tell whether none is left empty
+func fillProfile(profile *Profile, src Profile) bool {
+	dst, from := reflect.ValueOf(profile).Elem(), reflect.ValueOf(src)
+	complete := true
+	for i := 0; i < dst.NumField(); i++ {
+		if dst.Field(i).IsZero() {
+			dst.Field(i).Set(from.Field(i))
+		}
+		if dst.Field(i).IsZero() {
+			complete = false
+		}
+	}
+	return complete
+}
+
+func fallback[T any](ctx context.Context, providers []MarketDataProvider, call func(MarketDataProvider) (T, error)) (T, error) {
+	var zero T
+	err := ErrNotSupported
+	for _, p := range providers {
+		var result T
+		result, err = call(p)
+		if err == nil {
+			return result, nil
+		}
+		// Stop when the caller has given up
+		if ctx.Err() != nil {
+			return zero, err
+		}
+		if !errors.Is(err, ErrNotSupported) {
+			log.Printf("Provider %s failed, trying next: %v", p.Name(), err)
+		}
+	}
+	return zero, err
+}
@@ -0,0 +1,174 @@
+package marketdata_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"server/marketdata"
+)
+
+// Provider that only has a profile
+type profileProvider struct {
+	name    string
+	profile marketdata.Profile
+	err     error
+	calls   int
+}
+
+func (p *profileProvider) Name() string { return p.name }
+
+func (p *profileProvider) Quote(ctx context.Context, code string) (marketdata.Quote, error) {
+	return marketdata.Quote{}, marketdata.ErrNotSupported
+}
+
+func (p *profileProvider) News(ctx context.Context, code string, opts marketdata.NewsOptions) ([]marketdata.Article, error) {
+	return nil, marketdata.ErrNotSupported
+}
+
+func (p *profileProvider) Profile(ctx context.Context, code string) (marketdata.Profile, error) {
+	p.calls++
+	return p.profile, p.err
+}
+
+// Every field set, as a single site never has
+func fullProfile() marketdata.Profile {
+	return marketdata.Profile{
+		Code: "4385", StockName: "メルカリ", MarketType: "東証プライム", CompanyName: "株式会社メルカリ",
+		EnglishCompanyName: "Mercari, Inc.", Industry: "情報・通信", Representative: "山田 進太郎", SettlementMonth: "6月",
+		Capital: "48,910百万円", Address: "東京都港区", Phone: "03-0000-0000", ListingMarket: "東証プライム",
+		ListingDate: "2018年6月19日", UnitShares: "100株", Feature: "フリマアプリ", Business: "マーケットプレイス",
+		EmployeesSolo: 1500, EmployeesConsolidated: 2190, AverageAge: 36, AverageSalary: 10_000_000,
+	}
+}
+
+func TestFallbackProfileMergesProviders(t *testing.T) {
+	full := fullProfile()
+
+	// Minkabu has the listing but no employees or business, which Yahoo has
+	minkabu := &profileProvider{name: "minkabu", profile: full}
+	minkabu.profile.Feature, minkabu.profile.Business = "", ""
+	minkabu.profile.EmployeesSolo, minkabu.profile.EmployeesConsolidated = 0, 0
+	minkabu.profile.AverageAge, minkabu.profile.AverageSalary = 0, 0
+	yahoo := &profileProvider{name: "yahoo", profile: full}
+	yahoo.profile.CompanyName = "Yahoo の社名"
+	last := &profileProvider{name: "last", profile: full}
+
+	got, err := marketdata.Fallback{minkabu, yahoo, last}.Profile(context.Background(), "4385")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := full
+	if got != want {
+		t.Errorf("Profile() = %+v, want %+v", got, want)
+	}
+	if last.calls != 0 {
+		t.Errorf("the last provider was asked %d times after the profile was complete", last.calls)
+	}
+}
+
+func TestFallbackProfileSkipsFailures(t *testing.T) {
+	failed := &profileProvider{name: "minkabu", err: errors.New("timeout")}
+	partial := &profileProvider{name: "yahoo", profile: marketdata.Profile{Code: "4385", Feature: "フリマアプリ"}}
+
+	got, err := marketdata.Fallback{failed, partial}.Profile(context.Background(), "4385")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Feature != "フリマアプリ" || got.CompanyName != "" {
+		t.Errorf("Profile() = %+v, want the partial profile", got)
+	}
+
+	_, err = marketdata.Fallback{failed, &profileProvider{name: "fixture", err: marketdata.ErrNotSupported}}.Profile(context.Background(), "4385")
+	if !errors.Is(err, marketdata.ErrNotSupported) {
+		t.Errorf("Profile() error = %v, want the error of the last provider", err)
+	}
+}
+
+// Provider with news pages of one article each, failing at failPage if set
+type newsProvider struct {
+	name     string
+	pages    int
+	failPage int
+}
+
+func (p *newsProvider) Name() string { return p.name }
+
+func (p *newsProvider) Quote(ctx context.Context, code string) (marketdata.Quote, error) {
+	return marketdata.Quote{}, marketdata.ErrNotSupported
+}
+
+func (p *newsProvider) News(ctx context.Context, code string, opts marketdata.NewsOptions) ([]marketdata.Article, error) {
+	return marketdata.CollectNews(ctx, marketdata.Pager{}, opts, func(ctx context.Context, page int) (marketdata.NewsPage, error) {
+		if page == p.failPage {
+			return marketdata.NewsPage{}, errors.New("page failed")
+		}
+		article := marketdata.Article{
+			Title:  p.name + " " + fmt.Sprint(page),
+			Link:   "https://example.com/" + p.name + "/" + fmt.Sprint(page),
+			Source: "適時開示",
+			Date:   time.Now().AddDate(0, 0, -page).Format("2006/01/02 15:04"),
+		}
+		return marketdata.NewsPage{Articles: []marketdata.Article{article}, Last: page == p.pages}, nil
+	})
+}
+
+func (p *newsProvider) Profile(ctx context.Context, code string) (marketdata.Profile, error) {
+	return marketdata.Profile{}, marketdata.ErrNotSupported
+}
+
+func TestFallbackNewsStreamsTheSucceedingProvider(t *testing.T) {
+	tests := []struct {
+		name      string
+		providers marketdata.Fallback
+		want      []string // Titles passed to OnPage
+		lastPage  bool
+		wantErr   bool
+	}{
+		{
+			"failed provider held back",
+			marketdata.Fallback{&newsProvider{name: "minkabu", pages: 5, failPage: 3}, &newsProvider{name: "yahoo", pages: 2}},
+			[]string{"yahoo 1", "yahoo 2"}, true, false,
+		},
+		{
+			"first provider succeeds",
+			marketdata.Fallback{&newsProvider{name: "minkabu", pages: 2}, &newsProvider{name: "yahoo", pages: 2}},
+			[]string{"minkabu 1", "minkabu 2"}, true, false,
+		},
+		{
+			// The last provider streams as it reads, having no other to fall back to
+			"every provider fails",
+			marketdata.Fallback{&newsProvider{name: "minkabu", pages: 5, failPage: 3}, &newsProvider{name: "yahoo", pages: 5, failPage: 2}},
+			[]string{"yahoo 1"}, false, true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var streamed []string
+			coverage := marketdata.NewsCoverage{LastPage: true, Known: true} // Left over from an earlier crawl
+			opts := marketdata.NewsOptions{
+				Sources:  []string{},
+				Coverage: &coverage,
+				OnPage: func(page int, articles []marketdata.Article) {
+					for _, article := range articles {
+						streamed = append(streamed, article.Title)
+					}
+				},
+			}
+
+			_, err := tt.providers.News(context.Background(), "4385", opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(streamed, tt.want) {
+				t.Errorf("OnPage got %v, want %v", streamed, tt.want)
+			}
+			if coverage.LastPage != tt.lastPage || coverage.Known {
+				t.Errorf("coverage = %+v, want LastPage %v of the succeeding provider only", coverage, tt.lastPage)
+			}
+		})
+	}
+}
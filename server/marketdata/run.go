@@ -0,0 +1,119 @@
+package marketdata
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// Run is the outcome of one page visit of a scraper
+type Run struct {
+	Source          string
+	URL             string
+	StartedAt       time.Time
+	Duration        time.Duration
+	HTTPStatus      int
+	FieldsExtracted int
+	MissingFields   []string
+	Err             error
+}
+
+// RunRecorder receives the outcome of every page visit
+type RunRecorder func(Run)
+
+var (
+	recorderMu sync.RWMutex
+	recorder   RunRecorder
+)
+
+// SetRunRecorder installs the function that receives the scrape runs
+func SetRunRecorder(r RunRecorder) {
+	recorderMu.Lock()
+	defer recorderMu.Unlock()
+	recorder = r
+}
+
+func recordRun(run Run) {
+	recorderMu.RLock()
+	r := recorder
+	recorderMu.RUnlock()
+	if r != nil {
+		r(run)
+	}
+}
+
+// PageTracker reports each page visited by a collector as a Run.
+// Callbacks call Found for every field they extract from the current page.
+type PageTracker struct {
+	c      *colly.Collector
+	mu     sync.Mutex
+	status int
+	fields map[string]int
+}
+
+// TrackPages records the HTTP status of the pages visited by c
+func TrackPages(c *colly.Collector) *PageTracker {
+	t := &PageTracker{c: c, fields: map[string]int{}}
+	c.OnResponse(func(r *colly.Response) {
+		t.setStatus(r.StatusCode)
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		t.setStatus(r.StatusCode)
+	})
+	return t
+}
+
+func (t *PageTracker) setStatus(status int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status = status
+}
+
+// Status is the HTTP status of the page visited last, or 0 if no response was received
+func (t *PageTracker) Status() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
+// Found counts a value extracted from the current page
+func (t *PageTracker) Found(field string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.fields[field]++
+}
+
+// Visit fetches url and reports the run under source.
+// The expected fields that were not found are reported as missing.
+func (t *PageTracker) Visit(source, url string, expected ...string) error {
+	t.mu.Lock()
+	t.status = 0
+	t.fields = map[string]int{}
+	t.mu.Unlock()
+
+	start := time.Now()
+	err := t.c.Visit(url)
+
+	t.mu.Lock()
+	run := Run{
+		Source:     source,
+		URL:        url,
+		StartedAt:  start,
+		Duration:   time.Since(start),
+		HTTPStatus: t.status,
+		Err:        err,
+	}
+	for _, count := range t.fields {
+		run.FieldsExtracted += count
+	}
+	for _, field := range expected {
+		if t.fields[field] == 0 {
+			run.MissingFields = append(run.MissingFields, field)
+		}
+	}
+	t.mu.Unlock()
+
+	recordRun(run)
+	return err
+}
@@ -0,0 +1,196 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"server/normalize"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// YahooBaseURL is the address of Yahoo! Finance Japan
+const YahooBaseURL = "https://finance.yahoo.co.jp"
+
+// Yahoo scrapes finance.yahoo.co.jp, or a site with the same markup at BaseURL.
+// The quote and the company profile are supported, but not the news.
+type Yahoo struct {
+	BaseURL string
+}
+
+func NewYahoo(baseURL string) *Yahoo {
+	return &Yahoo{BaseURL: baseURL}
+}
+
+func (y *Yahoo) Name() string {
+	return "yahoo"
+}
+
+func (y *Yahoo) newCollector(ctx context.Context) *colly.Collector {
+	c := colly.NewCollector(
+		colly.AllowedDomains(Hostname(y.BaseURL)),
+	)
+	BindContext(ctx, c)
+
+	// Limit the request rate
+	c.Limit(&colly.LimitRule{
+		DomainGlob:  "*yahoo.co.jp",
+		Delay:       2 * time.Second,
+		RandomDelay: 1 * time.Second,
+	})
+
+	return c
+}
+
+// Quote reads the price board and the reference indicators of the quote page.
+// The class names end with a hash that changes with each release of the site, so only their prefixes are matched.
+// AveragePER and AveragePBR are the forecast PER and the actual PBR shown on the page.
+func (y *Yahoo) Quote(ctx context.Context, code string) (Quote, error) {
+	c := y.newCollector(ctx)
+	pages := TrackPages(c)
+
+	quote := Quote{Code: code}
+
+	c.OnHTML(`[class*="PriceBoard__main__"]`, func(e *colly.HTMLElement) {
+		if price, err := normalize.Price(e.ChildText(`[class*="PriceBoard__price__"] [class*="StyledNumber__value"]`)); err == nil {
+			quote.StockPrice = price
+			pages.Found("stock_price")
+		}
+		diff := e.ChildText(`[class*="PriceChangeLabel__primary"]`) + e.ChildText(`[class*="PriceChangeLabel__secondary"]`)
+		if change, percent, err := normalize.Change(diff); err == nil {
+			quote.PriceChange = change
+			quote.PriceChangePercent = percent
+			pages.Found("price_change")
+		}
+		quote.StopHigh = strings.Contains(e.Text, "ストップ高")
+	})
+
+	c.OnHTML(`li[class^="DataListItem__"]`, func(e *colly.HTMLElement) {
+		label := strings.TrimSpace(e.ChildText(`[class*="DataListItem__name"]`))
+		value := strings.TrimSpace(e.ChildText(`[class*="DataListItem__value"]`))
+		value = strings.TrimPrefix(strings.TrimPrefix(value, "(連)"), "(単)")
+
+		switch label {
+		case "前日終値":
+			if prevClose, err := normalize.Price(value); err == nil {
+				quote.PrevClose = prevClose
+				pages.Found("prev_close")
+			}
+		case "時価総額":
+			if marketCap, err := normalize.Yen(value); err == nil {
+				quote.MarketCap = marketCap
+				pages.Found("market_cap")
+			}
+		case "発行済株式数":
+			if issuedShares, err := normalize.Shares(value); err == nil {
+				quote.IssuedShares = issuedShares
+				pages.Found("issued_shares")
+			}
+		case "PER":
+			if per, err := normalize.Price(strings.TrimSuffix(value, "倍")); err == nil {
+				quote.AveragePER = per
+				pages.Found("per")
+			}
+		case "PBR":
+			if pbr, err := normalize.Price(strings.TrimSuffix(value, "倍")); err == nil {
+				quote.AveragePBR = pbr
+				pages.Found("pbr")
+			}
+		}
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Failed to crawl %s: %v", r.Request.URL, err)
+	})
+
+	quoteURL := fmt.Sprintf("%s/quote/%s.T", y.BaseURL, code)
+	if err := pages.Visit("yahoo.quote", quoteURL, "stock_price", "price_change", "prev_close", "market_cap", "issued_shares", "per", "pbr"); err != nil {
+		return quote, fmt.Errorf("visit %s: %w", quoteURL, err)
+	}
+
+	if quote.StockPrice == 0 {
+		return quote, fmt.Errorf("%s: %w", quoteURL, ErrNoData)
+	}
+
+	return quote, nil
+}
+
+func (y *Yahoo) News(ctx context.Context, code string, opts NewsOptions) ([]Article, error) {
+	return nil, ErrNotSupported
+}
+
+func (y *Yahoo) Profile(ctx context.Context, code string) (Profile, error) {
+	profile := Profile{Code: code}
+	table, err := y.ProfileTable(ctx, code)
+	if err != nil {
+		return profile, err
+	}
+
+	for header, value := range table {
+		switch header {
+		case "特色":
+			profile.Feature = value
+		case "連結事業":
+			profile.Business = value
+		case "従業員数（単独）":
+			employees, _ := normalize.Count(value)
+			profile.EmployeesSolo = int(employees)
+		case "従業員数（連結）":
+			employees, _ := normalize.Count(value)
+			profile.EmployeesConsolidated = int(employees)
+		case "平均年齢":
+			profile.AverageAge, _ = normalize.Age(value)
+		case "平均年収":
+			salary, _ := normalize.Yen(value)
+			profile.AverageSalary = int(salary)
+		}
+	}
+	return profile, nil
+}
+
+// ProfileTable reads the company information table of the profile page, keyed by the headers.
+// The values are kept as displayed, e.g. "11,660千円", for the stock details file of the crawler.
+func (y *Yahoo) ProfileTable(ctx context.Context, code string) (map[string]string, error) {
+	c := y.newCollector(ctx)
+	pages := TrackPages(c)
+
+	var table map[string]string
+
+	// Extract information based on the corresponding table headers
+	c.OnHTML("table.CompanyInformationDetail__table__BIq9", func(e *colly.HTMLElement) {
+		table = map[string]string{}
+		e.ForEach("tr", func(_ int, row *colly.HTMLElement) {
+			header := row.ChildText("th")
+			value := strings.TrimSpace(row.ChildText("td"))
+
+			// Clean up the extracted text
+			value = strings.ReplaceAll(value, "【特色】", "")
+			value = strings.ReplaceAll(value, "【連結事業】", "")
+			if value != "" && value != "---" {
+				pages.Found(header)
+			}
+			table[header] = value
+		})
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Failed to crawl %s: %v", r.Request.URL, err)
+	})
+
+	profileURL := fmt.Sprintf("%s/quote/%s.T/profile", y.BaseURL, code)
+	if err := pages.Visit("yahoo.profile", profileURL, "特色", "連結事業", "従業員数（連結）", "平均年齢", "平均年収"); err != nil {
+		if pages.Status() == http.StatusNotFound {
+			return nil, fmt.Errorf("%s: %w", profileURL, ErrNotFound)
+		}
+		return nil, fmt.Errorf("visit %s: %w", profileURL, err)
+	}
+
+	if table == nil {
+		return nil, fmt.Errorf("%s: %w", profileURL, ErrNoData)
+	}
+
+	return table, nil
+}
@@ -0,0 +1,29 @@
+package marketdata_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"server/fixtureserver"
+	"server/marketdata"
+)
+
+func TestYahooProfile(t *testing.T) {
+	server := fixtureserver.New(filepath.Join("testdata", "html", "yahoo"))
+	defer server.Close()
+	yahoo := marketdata.NewYahoo(server.URL)
+	hosts := strings.NewReplacer(server.URL, marketdata.YahooBaseURL)
+
+	checkGolden(t, "yahoo_profile.json", hosts, func(ctx context.Context) (any, error) { return yahoo.Profile(ctx, code) })
+}
+
+func TestYahooQuote(t *testing.T) {
+	server := fixtureserver.New(filepath.Join("testdata", "html", "yahoo"))
+	defer server.Close()
+	yahoo := marketdata.NewYahoo(server.URL)
+	hosts := strings.NewReplacer(server.URL, marketdata.YahooBaseURL)
+
+	checkGolden(t, "yahoo_quote.json", hosts, func(ctx context.Context) (any, error) { return yahoo.Quote(ctx, code) })
+}
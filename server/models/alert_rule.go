@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+type AlertCondition string
+
+const (
+	PriceAbove    AlertCondition = "price_above"    // Price at or above the threshold
+	PriceBelow    AlertCondition = "price_below"    // Price at or below the threshold
+	ChangePercent AlertCondition = "change_percent" // Daily change of at least the threshold percent, either way
+	StopHighHit   AlertCondition = "stop_high"      // STOP高 reached, the threshold is ignored
+	PERAbove      AlertCondition = "per_above"      // Average PER at or above the threshold
+)
+
+// AlertRule creates an urgent event when a fetched quote meets its condition
+type AlertRule struct {
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	StockCode       int            `json:"stock_code" gorm:"index;not null"`
+	Condition       AlertCondition `json:"condition" gorm:"type:text;not null"`
+	Threshold       float64        `json:"threshold"`
+	DeadlineDays    int            `json:"deadline_days"` // Days to the deadline of the created event, 0 for the same day
+	Enabled         bool           `json:"enabled"`
+	LastTriggeredAt *time.Time     `json:"last_triggered_at"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// AlertTrigger records that a rule triggered on a trading date.
+// The unique key keeps concurrent evaluations from creating a second event on the same day.
+type AlertTrigger struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	RuleID      uint      `json:"rule_id" gorm:"uniqueIndex:idx_alert_trigger_rule_date;not null"`
+	TradingDate time.Time `json:"trading_date" gorm:"uniqueIndex:idx_alert_trigger_rule_date;not null"`
+	EventID     uint      `json:"event_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Validation
+func (r *AlertRule) Validate() error {
+	switch r.Condition {
+	case PriceAbove, PriceBelow, ChangePercent, PERAbove:
+		if r.Threshold <= 0 {
+			return errors.New("threshold must be positive")
+		}
+	case StopHighHit:
+	default:
+		return errors.New("invalid condition value")
+	}
+	if r.DeadlineDays < 0 {
+		return errors.New("deadline_days must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Kind of the item an ArticleStock links
+type ArticleType string
+
+const (
+	NewsArticleType ArticleType = "news"      // NewsArticle
+	MilestoneType   ArticleType = "milestone" // Milestone
+)
+
+// ArticleStock links a news article or milestone to a stock whose name it mentions
+type ArticleStock struct {
+	ID          uint        `json:"-" gorm:"primaryKey"`
+	ArticleType ArticleType `json:"-" gorm:"type:text;not null;uniqueIndex:idx_article_stock"`
+	ArticleID   uint        `json:"-" gorm:"not null;uniqueIndex:idx_article_stock"`
+	StockCode   int         `json:"stock_code" gorm:"not null;uniqueIndex:idx_article_stock;index"`
+	StockName   string      `json:"stock_name"`
+	MatchedName string      `json:"matched_name"` // Name or code found in the text
+	CreatedAt   time.Time   `json:"-"`
+}
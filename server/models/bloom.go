@@ -1,8 +1,22 @@
 package models
 
+import "time"
+
 type NewsArticle struct {
 	ID          uint   `json:"id" gorm:"primaryKey"`
 	Title       string `json:"title"  gorm:"not null"`
 	Link        string `json:"link" gorm:"unique;not null"`
+	Source      string `json:"source" gorm:"index"` // Name of the news source
 	Description string `json:"description"`
+
+	// Fetches of the headlines that first and last found the article on the homepage
+	FirstSeenAt *time.Time `json:"first_seen_at"`
+	LastSeenAt  *time.Time `json:"last_seen_at"`
+
+	// Metadata from the JSON-LD of the article page
+	PublishedAt *time.Time `json:"published_at" gorm:"index"`
+	Author      string     `json:"author"`
+	Section     string     `json:"section"`
+	ImageURL    string     `json:"image_url"`
+	EnrichedAt  *time.Time `json:"enriched_at"` // When the article page was read, nil until then
 }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type NewsSourceKind string
+
+const (
+	BloombergSource   NewsSourceKind = "bloomberg"    // Headlines of the Bloomberg homepage at the URL
+	MinkabuNewsSource NewsSourceKind = "minkabu_news" // Market news page of minkabu at the URL
+	FeedSource        NewsSourceKind = "feed"         // RSS or Atom feed at the URL
+)
+
+// NewsSource is a configured source of market-wide news
+type NewsSource struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Name      string         `json:"name" gorm:"uniqueIndex;not null"` // Source of the fetched articles
+	Kind      NewsSourceKind `json:"kind" gorm:"type:text;not null"`
+	URL       string         `json:"url" gorm:"not null"` // Base URL of the site, or address of the feed
+	Enabled   bool           `json:"enabled"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// Validation
+func (s *NewsSource) Validate() error {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return errors.New("name is required")
+	}
+	switch s.Kind {
+	case BloombergSource, MinkabuNewsSource, FeedSource:
+	default:
+		return errors.New("invalid kind value")
+	}
+	if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an http or https address")
+	}
+	return nil
+}
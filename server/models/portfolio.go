@@ -0,0 +1,76 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type TradeSide string
+
+const (
+	Buy  TradeSide = "buy"
+	Sell TradeSide = "sell"
+)
+
+// Portfolio Model
+type Portfolio struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Trade is a buy or sell of a stock in a portfolio
+type Trade struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PortfolioID uint      `json:"portfolio_id" gorm:"index;not null"`
+	StockCode   int       `json:"stock_code" gorm:"index;not null"`
+	Side        TradeSide `json:"side" gorm:"type:text;not null"`
+	Quantity    int       `json:"quantity" gorm:"not null"`
+	Price       float64   `json:"price" gorm:"not null"`
+	Fees        float64   `json:"fees"`
+	TradedAt    time.Time `json:"traded_at" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	Portfolio Portfolio `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// Holding is the current position of a stock in a portfolio, derived from its trades
+type Holding struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PortfolioID uint      `json:"portfolio_id" gorm:"uniqueIndex:idx_holding_portfolio_stock;not null"`
+	StockCode   int       `json:"stock_code" gorm:"uniqueIndex:idx_holding_portfolio_stock;not null"`
+	Quantity    int       `json:"quantity"`
+	AverageCost float64   `json:"average_cost"` // Per share, fees included
+	CostBasis   float64   `json:"cost_basis"`   // Of the shares still held
+	RealizedPnL float64   `json:"realized_pnl"` // From the shares already sold
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Portfolio Portfolio `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Stock     Stock     `json:"stock" gorm:"foreignKey:StockCode;references:StockCode"`
+}
+
+// Validation
+func (t *Trade) Validate(unitShares int) error {
+	if t.Side != Buy && t.Side != Sell {
+		return errors.New("invalid side value")
+	}
+	if t.Quantity <= 0 {
+		return errors.New("quantity must be positive")
+	}
+	if unitShares > 0 && t.Quantity%unitShares != 0 {
+		return fmt.Errorf("quantity must be a multiple of the unit shares (%d)", unitShares)
+	}
+	if t.Price <= 0 {
+		return errors.New("price must be positive")
+	}
+	if t.Fees < 0 {
+		return errors.New("fees must not be negative")
+	}
+	if t.TradedAt.IsZero() {
+		return errors.New("traded_at is required")
+	}
+	return nil
+}
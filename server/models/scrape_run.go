@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ScrapeRun is the outcome of one page visit of a scraper
+type ScrapeRun struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	Source          string    `gorm:"index;not null" json:"source"` // e.g. "minkabu.quote"
+	URL             string    `json:"url"`
+	StartedAt       time.Time `gorm:"index" json:"started_at"`
+	DurationMs      int64     `json:"duration_ms"`
+	HTTPStatus      int       `json:"http_status"`
+	FieldsExtracted int       `json:"fields_extracted"`
+	MissingFields   string    `json:"missing_fields"` // Comma separated
+	Error           string    `json:"error"`
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// StockDisclosure is an article on the news pages of a stock, e.g. a timely disclosure (適時開示) or PR TIMES release
+type StockDisclosure struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	StockCode   int        `json:"stock_code" gorm:"not null;index:idx_stock_disclosure_published,priority:1"`
+	Title       string     `json:"title" gorm:"not null"`
+	Link        string     `json:"link" gorm:"uniqueIndex;not null"`
+	Source      string     `json:"source"`                                                              // Provider shown on the page, e.g. 適時開示
+	Date        string     `json:"date"`                                                                // As shown on the page
+	PublishedAt *time.Time `json:"published_at" gorm:"index:idx_stock_disclosure_published,priority:2"` // Parsed date in JST, nil if it did not parse
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// StockNewsCoverage is how far back the saved disclosures of a stock are complete
+type StockNewsCoverage struct {
+	StockCode int       `json:"stock_code" gorm:"primaryKey;autoIncrement:false"`
+	Since     time.Time `json:"since"`      // Every article published since then is saved
+	AllPages  bool      `json:"all_pages"`  // The news pages were read to the last one, so no older article exists
+	FetchedAt time.Time `json:"fetched_at"` // Last fetch, up to which the articles are saved
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // type Stock struct {
 // 	ID                 uint `gorm:"primaryKey"`
 // 	StockCode          int  `gorm:"uniqueIndex"`
@@ -49,6 +51,8 @@ type Stock struct {
 	ListingMarket      string `json:"listing_market"`
 	ListingDate        string `json:"listing_date"`
 	UnitShares         int    `json:"unit_shares"`
+
+	DelistedAt *time.Time `json:"delisted_at"` // Time of the import that first missed the code
 }
 
 type StockDetail struct {
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// StockMasterChange is one field of the stock master data changed by an import
+type StockMasterChange struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	StockCode   int       `gorm:"index;not null" json:"stock_code"`
+	Field       string    `gorm:"not null" json:"field"` // Column of stocks or stock_details
+	OldValue    string    `json:"old_value"`
+	NewValue    string    `json:"new_value"`
+	ImportBatch string    `gorm:"index;not null" json:"import_batch"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// StockMasterImport is one import of a pair of stock master CSV files
+type StockMasterImport struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Batch           string     `gorm:"uniqueIndex;not null" json:"batch"` // Import batch of the logged changes
+	DatasetMonth    string     `json:"dataset_month"`                     // e.g. "202503", empty for uploaded files
+	StockFile       string     `json:"stock_file"`
+	StockDetailFile string     `json:"stock_detail_file"`
+	Inserted        int        `json:"inserted"`
+	Updated         int        `json:"updated"`
+	Unchanged       int        `json:"unchanged"`
+	Failed          int        `json:"failed"`
+	Delisted        int        `json:"delisted"`
+	Error           string     `json:"error"`
+	StartedAt       time.Time  `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// StockQuote is a daily snapshot of the values scraped for a stock.
+// One row is kept per stock code and trading date; later fetches on the
+// same day overwrite the earlier snapshot.
+type StockQuote struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	StockCode          int       `gorm:"uniqueIndex:idx_stock_quote_code_date;not null" json:"stock_code"`
+	TradingDate        time.Time `gorm:"uniqueIndex:idx_stock_quote_code_date;not null" json:"trading_date"`
+	StockPrice         float64   `json:"stock_price"`
+	PrevClose          float64   `json:"prev_close"`
+	PriceChange        float64   `json:"price_change"`
+	PriceChangePercent float64   `json:"price_change_percent"`
+	MarketCap          int64     `json:"market_cap"`
+	IssuedShares       int64     `json:"issued_shares"`
+	AveragePER         float64   `json:"average_per"`
+	AveragePBR         float64   `json:"average_pbr"`
+	StopHigh           bool      `json:"stop_high"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
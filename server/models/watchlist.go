@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// WatchlistItem is a stock whose quote is refreshed in the background
+type WatchlistItem struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	StockCode int       `gorm:"uniqueIndex;not null" json:"stock_code"`
+	Note      string    `json:"note"`
+	SortOrder int       `json:"sort_order"` // Ascending, then by code
+	CreatedAt time.Time `json:"created_at"`
+
+	Stock Stock `gorm:"foreignKey:StockCode;references:StockCode;constraint:OnDelete:CASCADE" json:"stock"`
+}
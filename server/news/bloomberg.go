@@ -0,0 +1,113 @@
+package news
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"server/marketdata"
+	"server/models"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// BloombergBaseURL is the address of Bloomberg Japan
+const BloombergBaseURL = "https://www.bloomberg.co.jp"
+
+// Bloomberg collects the headlines of the Bloomberg homepage,
+// or of a site with the same markup at BaseURL
+type Bloomberg struct {
+	BaseURL string
+}
+
+func NewBloomberg(baseURL string) *Bloomberg {
+	return &Bloomberg{BaseURL: baseURL}
+}
+
+// Fetch returns the articles linked from the homepage, once each in the order they first appear.
+// An article is linked several times (headline, image, related links), so the title is taken from its best anchor.
+func (b *Bloomberg) Fetch(ctx context.Context) ([]models.NewsArticle, error) {
+	var articles []models.NewsArticle
+	index := map[string]int{} // Canonical link -> position in articles
+	ranks := map[string]int{} // Canonical link -> rank of the anchor of the title
+
+	// Colly Instance
+	c := colly.NewCollector(
+		colly.AllowedDomains(marketdata.Hostname(b.BaseURL)), // Restrict to bloomberg.co.jp
+	)
+	marketdata.BindContext(ctx, c)
+	pages := marketdata.TrackPages(c)
+
+	// Limit the rate of requests
+	c.Limit(&colly.LimitRule{
+		DomainGlob:  "*bloomberg.co.jp",
+		Delay:       2 * time.Second,
+		RandomDelay: 1 * time.Second,
+	})
+
+	// Extract article titles and links
+	c.OnHTML("a[href]", func(e *colly.HTMLElement) {
+		link := e.Attr("href")
+		absoluteURL := e.Request.AbsoluteURL(link) // Convert relative URL to absolute URL
+		title := strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(e.Text, "\n", ""), "\t", ""))
+
+		// Filter out empty titles and only include articles
+		if title == "" || !strings.HasPrefix(absoluteURL, b.BaseURL+"/news/articles") {
+			return
+		}
+
+		canonical := CanonicalLink(absoluteURL)
+		rank := anchorRank(link, title)
+		i, seen := index[canonical]
+		if !seen {
+			index[canonical] = len(articles)
+			ranks[canonical] = rank
+			articles = append(articles, models.NewsArticle{Title: title, Link: canonical})
+			pages.Found("articles")
+			return
+		}
+		if rank > ranks[canonical] {
+			ranks[canonical] = rank
+			articles[i].Title = title
+		}
+	})
+
+	// Error handling
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Request URL: %s, Error: %v", r.Request.URL, err)
+	})
+
+	// Start the crawl
+	err := pages.Visit("bloomberg.top", b.BaseURL+"/", "articles")
+	if err != nil {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// CanonicalLink returns the link of an article without the query and fragment,
+// which only tell where on the page the link was
+func CanonicalLink(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// Rank of an anchor as the title of its article: a headline is preferred to a related link,
+// which has a fragment, and a longer text to a shorter one
+func anchorRank(href, title string) int {
+	rank := utf8.RuneCountInString(title)
+	if !strings.Contains(href, "#") {
+		rank += 1000
+	}
+	return rank
+}
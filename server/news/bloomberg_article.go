@@ -0,0 +1,170 @@
+package news
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"server/marketdata"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// ArticleDetails are the metadata of an article page, read from its JSON-LD
+type ArticleDetails struct {
+	Description string     `json:"description"`
+	PublishedAt *time.Time `json:"published_at"`
+	Author      string     `json:"author"`
+	Section     string     `json:"section"`
+	ImageURL    string     `json:"image_url"`
+}
+
+// NewsArticle object of the JSON-LD script of an article page.
+// Author, section and image are a string, an object or a list of them depending on the page.
+type jsonLDArticle struct {
+	Type           json.RawMessage `json:"@type"`
+	Description    string          `json:"description"`
+	DatePublished  string          `json:"datePublished"`
+	Author         json.RawMessage `json:"author"`
+	ArticleSection json.RawMessage `json:"articleSection"`
+	Image          json.RawMessage `json:"image"`
+}
+
+// Articles visits the article pages one after another within the rate limit and reads their details.
+// Pages that answer with an error status have empty details, so that they are not visited again;
+// links that could not be fetched at all are missing from the result.
+// When ctx ends, the details read so far are returned with its error.
+func (b *Bloomberg) Articles(ctx context.Context, links []string) (map[string]ArticleDetails, error) {
+	details := map[string]ArticleDetails{}
+
+	c := colly.NewCollector(
+		colly.AllowedDomains(marketdata.Hostname(b.BaseURL)),
+	)
+	marketdata.BindContext(ctx, c)
+	pages := marketdata.TrackPages(c)
+
+	// Limit the rate of requests
+	c.Limit(&colly.LimitRule{
+		DomainGlob:  "*bloomberg.co.jp",
+		Delay:       2 * time.Second,
+		RandomDelay: 1 * time.Second,
+	})
+
+	// The pages are visited one after another, so the callbacks fill the details of the current link
+	var current string
+	c.OnResponse(func(r *colly.Response) {
+		details[current] = ArticleDetails{}
+	})
+
+	// Extract the metadata of the article
+	c.OnHTML(`script[type="application/ld+json"]`, func(e *colly.HTMLElement) {
+		article, ok := findJSONLDArticle([]byte(e.Text))
+		if !ok {
+			return
+		}
+
+		d := details[current]
+		if article.Description != "" {
+			d.Description = strings.TrimSpace(article.Description)
+			pages.Found("description")
+		}
+		if publishedAt, err := time.Parse(time.RFC3339, article.DatePublished); err == nil {
+			d.PublishedAt = &publishedAt
+			pages.Found("published_at")
+		}
+		if authors := jsonLDNames(article.Author, "name"); len(authors) > 0 {
+			d.Author = strings.Join(authors, ", ")
+			pages.Found("author")
+		}
+		if sections := jsonLDNames(article.ArticleSection, "name"); len(sections) > 0 {
+			d.Section = sections[0]
+			pages.Found("section")
+		}
+		if images := jsonLDNames(article.Image, "url"); len(images) > 0 {
+			d.ImageURL = e.Request.AbsoluteURL(images[0])
+			pages.Found("image")
+		}
+		details[current] = d
+	})
+
+	// Error handling
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Request URL: %s, Error: %v", r.Request.URL, err)
+		if r.StatusCode != 0 {
+			details[current] = ArticleDetails{}
+		}
+	})
+
+	for _, link := range links {
+		if err := ctx.Err(); err != nil {
+			return details, err
+		}
+		// A failed page is logged and skipped
+		current = link
+		pages.Visit("bloomberg.article", link, "description", "published_at")
+	}
+	return details, ctx.Err()
+}
+
+// Find the NewsArticle in a JSON-LD script, which holds an object, a list or a @graph of them
+func findJSONLDArticle(data []byte) (jsonLDArticle, bool) {
+	var objects []json.RawMessage
+	if err := json.Unmarshal(data, &objects); err != nil {
+		objects = []json.RawMessage{data}
+	}
+
+	for _, object := range objects {
+		var node struct {
+			Graph json.RawMessage `json:"@graph"`
+		}
+		if err := json.Unmarshal(object, &node); err == nil && len(node.Graph) > 0 {
+			if article, ok := findJSONLDArticle(node.Graph); ok {
+				return article, true
+			}
+			continue
+		}
+
+		var article jsonLDArticle
+		if err := json.Unmarshal(object, &article); err != nil {
+			continue
+		}
+		for _, t := range jsonLDNames(article.Type, "") {
+			if strings.HasSuffix(t, "Article") {
+				return article, true
+			}
+		}
+	}
+	return jsonLDArticle{}, false
+}
+
+// Values of a JSON-LD property that is a string, an object with the key, or a list of them
+func jsonLDNames(raw json.RawMessage, key string) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		if s = strings.TrimSpace(s); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		var names []string
+		for _, item := range list {
+			names = append(names, jsonLDNames(item, key)...)
+		}
+		return names
+	}
+
+	var object map[string]json.RawMessage
+	if key != "" && json.Unmarshal(raw, &object) == nil {
+		return jsonLDNames(object[key], "")
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package news_test
+
+import (
+	"context"
+	"flag"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"server/fixtureserver"
+	"server/news"
+)
+
+// go test ./news -update rewrites the golden files after a selector change
+var update = flag.Bool("update", false, "rewrite the golden files with the current results")
+
+// Run the scraper against the pages saved under testdata and compare its result with testdata/golden
+func checkGolden(t *testing.T, golden string, hosts *strings.Replacer, run func(ctx context.Context) (any, error)) {
+	t.Helper()
+	result, err := run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtureserver.Golden(t, filepath.Join("testdata", "golden", golden), result, hosts, *update)
+}
+
+func TestBloomberg(t *testing.T) {
+	server := fixtureserver.New(filepath.Join("testdata", "html", "bloomberg"))
+	defer server.Close()
+	bloomberg := news.NewBloomberg(server.URL)
+	hosts := strings.NewReplacer(server.URL, news.BloombergBaseURL)
+
+	t.Run("bloomberg_top", func(t *testing.T) {
+		checkGolden(t, "bloomberg_top.json", hosts, func(ctx context.Context) (any, error) { return bloomberg.Fetch(ctx) })
+	})
+	t.Run("bloomberg_articles", func(t *testing.T) {
+		checkGolden(t, "bloomberg_articles.json", hosts, func(ctx context.Context) (any, error) {
+			return bloomberg.Articles(ctx, []string{
+				server.URL + "/news/articles/2025-03-17/SL9XK2T0G1KW00",
+				server.URL + "/news/articles/2025-03-17/SLA1B3DWX2PS00", // Not saved, answers 404
+			})
+		})
+	})
+}
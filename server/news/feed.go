@@ -0,0 +1,228 @@
+package news
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"server/marketdata"
+	"server/models"
+
+	"github.com/gocolly/colly/v2"
+	"golang.org/x/net/html/charset"
+)
+
+// Feed reads an RSS 2.0 or Atom feed at URL
+type Feed struct {
+	URL string
+}
+
+func NewFeed(url string) *Feed {
+	return &Feed{URL: url}
+}
+
+// Elements of an RSS 2.0 feed
+type rssFeed struct {
+	Items []struct {
+		Title       string   `xml:"title"`
+		Link        string   `xml:"link"`
+		Description string   `xml:"description"`
+		PubDate     string   `xml:"pubDate"`
+		Author      string   `xml:"author"`
+		Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+		Categories  []string `xml:"category"`
+		Enclosure   struct {
+			URL  string `xml:"url,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"enclosure"`
+		Media []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+		Thumb []mediaContent `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	} `xml:"channel>item"`
+}
+
+// Elements of an Atom feed
+type atomFeed struct {
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+			Type string `xml:"type,attr"`
+		} `xml:"link"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+		Authors   []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Categories []struct {
+			Term  string `xml:"term,attr"`
+			Label string `xml:"label,attr"`
+		} `xml:"category"`
+		Media []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+		Thumb []mediaContent `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	} `xml:"entry"`
+}
+
+// Image of the Media RSS extension
+type mediaContent struct {
+	URL    string `xml:"url,attr"`
+	Medium string `xml:"medium,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// Formats of the dates of RSS, which are not always RFC 1123 with a numeric zone
+var rssDateFormats = []string{time.RFC1123Z, time.RFC1123, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", time.RFC3339}
+
+func (f *Feed) Fetch(ctx context.Context) ([]models.NewsArticle, error) {
+	var articles []models.NewsArticle
+	var parseErr error
+
+	c := colly.NewCollector(
+		colly.AllowedDomains(marketdata.Hostname(f.URL)),
+	)
+	marketdata.BindContext(ctx, c)
+	pages := marketdata.TrackPages(c)
+
+	c.OnResponse(func(r *colly.Response) {
+		var root struct {
+			XMLName xml.Name
+		}
+		if err := decodeFeed(r.Body, &root); err != nil {
+			parseErr = err
+			return
+		}
+
+		switch root.XMLName.Local {
+		case "rss":
+			var feed rssFeed
+			if parseErr = decodeFeed(r.Body, &feed); parseErr == nil {
+				articles = feed.articles(r.Request)
+			}
+		case "feed":
+			var feed atomFeed
+			if parseErr = decodeFeed(r.Body, &feed); parseErr == nil {
+				articles = feed.articles(r.Request)
+			}
+		default:
+			parseErr = errors.New("not an RSS or Atom feed: <" + root.XMLName.Local + ">")
+		}
+		for range articles {
+			pages.Found("articles")
+		}
+	})
+
+	// Error handling
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Request URL: %s, Error: %v", r.Request.URL, err)
+	})
+
+	if err := pages.Visit("feed", f.URL, "articles"); err != nil {
+		return nil, err
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return articles, nil
+}
+
+func (feed rssFeed) articles(r *colly.Request) []models.NewsArticle {
+	var articles []models.NewsArticle
+	for _, item := range feed.Items {
+		article := models.NewsArticle{
+			Title:       strings.TrimSpace(item.Title),
+			Link:        r.AbsoluteURL(strings.TrimSpace(item.Link)),
+			Description: strings.TrimSpace(item.Description),
+			Author:      firstNonEmpty(item.Creator, item.Author),
+		}
+		if len(item.Categories) > 0 {
+			article.Section = strings.TrimSpace(item.Categories[0])
+		}
+		for _, format := range rssDateFormats {
+			if t, err := time.Parse(format, strings.TrimSpace(item.PubDate)); err == nil {
+				article.PublishedAt = &t
+				break
+			}
+		}
+		if strings.HasPrefix(item.Enclosure.Type, "image/") {
+			article.ImageURL = r.AbsoluteURL(item.Enclosure.URL)
+		} else if image := mediaImage(item.Media, item.Thumb); image != "" {
+			article.ImageURL = r.AbsoluteURL(image)
+		}
+		if article.Title != "" && article.Link != "" {
+			articles = append(articles, article)
+		}
+	}
+	return articles
+}
+
+func (feed atomFeed) articles(r *colly.Request) []models.NewsArticle {
+	var articles []models.NewsArticle
+	for _, entry := range feed.Entries {
+		article := models.NewsArticle{
+			Title:       strings.TrimSpace(entry.Title),
+			Description: strings.TrimSpace(firstNonEmpty(entry.Summary, entry.Content)),
+		}
+		// The page of the entry is the alternate link, which is the default relation
+		for _, link := range entry.Links {
+			if link.Rel == "" || link.Rel == "alternate" {
+				article.Link = r.AbsoluteURL(link.Href)
+				break
+			}
+		}
+		var authors []string
+		for _, author := range entry.Authors {
+			if name := strings.TrimSpace(author.Name); name != "" {
+				authors = append(authors, name)
+			}
+		}
+		article.Author = strings.Join(authors, ", ")
+		if len(entry.Categories) > 0 {
+			article.Section = firstNonEmpty(entry.Categories[0].Label, entry.Categories[0].Term)
+		}
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(firstNonEmpty(entry.Published, entry.Updated))); err == nil {
+			article.PublishedAt = &t
+		}
+		if image := mediaImage(entry.Media, entry.Thumb); image != "" {
+			article.ImageURL = r.AbsoluteURL(image)
+		}
+		if article.Title != "" && article.Link != "" {
+			articles = append(articles, article)
+		}
+	}
+	return articles
+}
+
+// Decode a feed in the encoding of its XML declaration, e.g. Shift_JIS
+func decodeFeed(body []byte, v interface{}) error {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charset.NewReaderLabel
+	return decoder.Decode(v)
+}
+
+// First image of the Media RSS contents, or else the first thumbnail
+func mediaImage(contents, thumbnails []mediaContent) string {
+	for _, content := range contents {
+		if content.Medium == "image" || strings.HasPrefix(content.Type, "image/") {
+			return content.URL
+		}
+	}
+	if len(thumbnails) > 0 {
+		return thumbnails[0].URL
+	}
+	return ""
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
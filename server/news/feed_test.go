@@ -0,0 +1,25 @@
+package news_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"server/news"
+)
+
+func TestFeed(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir(filepath.Join("testdata", "feeds"))))
+	defer server.Close()
+	hosts := strings.NewReplacer(server.URL, "https://feeds.example.jp")
+
+	for _, name := range []string{"rss", "atom"} {
+		t.Run("feed_"+name, func(t *testing.T) {
+			feed := news.NewFeed(server.URL + "/" + name + ".xml")
+			checkGolden(t, "feed_"+name+".json", hosts, func(ctx context.Context) (any, error) { return feed.Fetch(ctx) })
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package news
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"server/jpdate"
+	"server/marketdata"
+	"server/models"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// MinkabuNews collects the market news page of minkabu.jp,
+// or of a site with the same markup at BaseURL
+type MinkabuNews struct {
+	BaseURL string
+}
+
+func NewMinkabuNews(baseURL string) *MinkabuNews {
+	return &MinkabuNews{BaseURL: baseURL}
+}
+
+func (m *MinkabuNews) Fetch(ctx context.Context) ([]models.NewsArticle, error) {
+	var articles []models.NewsArticle
+	seen := map[string]bool{}
+	now := time.Now()
+
+	c := colly.NewCollector(
+		colly.AllowedDomains(marketdata.Hostname(m.BaseURL)),
+	)
+	marketdata.BindContext(ctx, c)
+	pages := marketdata.TrackPages(c)
+
+	// Limit the rate of requests
+	c.Limit(&colly.LimitRule{
+		DomainGlob:  "*minkabu.jp",
+		Delay:       1 * time.Second,
+		RandomDelay: 1 * time.Second,
+	})
+
+	// Extract the news items, with the provider of each as the section
+	c.OnHTML("li", func(e *colly.HTMLElement) {
+		title := strings.TrimSpace(e.ChildText(".title_box a"))
+		link := e.ChildAttr(".title_box a", "href")
+		if title == "" || link == "" {
+			return
+		}
+
+		absoluteURL := CanonicalLink(e.Request.AbsoluteURL(link))
+		if seen[absoluteURL] {
+			return
+		}
+		seen[absoluteURL] = true
+
+		article := models.NewsArticle{
+			Title:   title,
+			Link:    absoluteURL,
+			Section: strings.TrimSpace(e.ChildText(".fcgl")),
+		}
+		if publishedAt, err := jpdate.Parse(e.ChildText(".flex.items-center"), now); err == nil {
+			article.PublishedAt = &publishedAt
+		}
+		articles = append(articles, article)
+		pages.Found("articles")
+	})
+
+	// Error handling
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Request URL: %s, Error: %v", r.Request.URL, err)
+	})
+
+	if err := pages.Visit("minkabu.market_news", m.BaseURL+"/news", "articles"); err != nil {
+		return nil, err
+	}
+	return articles, nil
+}
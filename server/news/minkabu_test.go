@@ -0,0 +1,21 @@
+package news_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"server/fixtureserver"
+	"server/marketdata"
+	"server/news"
+)
+
+func TestMinkabuNews(t *testing.T) {
+	server := fixtureserver.New(filepath.Join("testdata", "html", "minkabu"))
+	defer server.Close()
+	minkabuNews := news.NewMinkabuNews(server.URL)
+	hosts := strings.NewReplacer(server.URL, marketdata.MinkabuBaseURL)
+
+	checkGolden(t, "minkabu_market_news.json", hosts, func(ctx context.Context) (any, error) { return minkabuNews.Fetch(ctx) })
+}
@@ -0,0 +1,47 @@
+// Package news fetches the headlines of the configured news sources:
+// the Bloomberg homepage, the minkabu market news page, and RSS 2.0 or Atom feeds.
+//
+// A listing with only the titles is an ArticleReader. After each fetch the server reads the
+// pages of the articles not read yet in the background, a batch at a time within the rate limit
+// of the site, filling their description, publish time, author, section and image.
+package news
+
+import (
+	"context"
+	"fmt"
+
+	"server/marketdata"
+	"server/models"
+)
+
+// NewsSource is a source of market-wide news articles
+type NewsSource interface {
+	Fetch(ctx context.Context) ([]models.NewsArticle, error)
+}
+
+// ArticleReader is a source whose listing only has the titles,
+// and that reads the other details of the articles from their pages
+type ArticleReader interface {
+	Articles(ctx context.Context, links []string) (map[string]ArticleDetails, error)
+}
+
+// New builds the news source of a configured kind
+func New(kind models.NewsSourceKind, url string) (NewsSource, error) {
+	switch kind {
+	case models.BloombergSource:
+		return NewBloomberg(url), nil
+	case models.MinkabuNewsSource:
+		return NewMinkabuNews(url), nil
+	case models.FeedSource:
+		return NewFeed(url), nil
+	}
+	return nil, fmt.Errorf("unknown news source kind: %q", kind)
+}
+
+// DefaultSources are the sources configured when there are none yet
+func DefaultSources() []models.NewsSource {
+	return []models.NewsSource{
+		{Name: "bloomberg", Kind: models.BloombergSource, URL: BloombergBaseURL, Enabled: true},
+		{Name: "minkabu", Kind: models.MinkabuNewsSource, URL: marketdata.MinkabuBaseURL, Enabled: true},
+	}
+}
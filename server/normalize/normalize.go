@@ -0,0 +1,159 @@
+// Package normalize parses the display strings of the scraped sites
+// (e.g. "307,216百万円", "164,993千株", "+21(+1.14%)") into typed values.
+package normalize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/width"
+)
+
+// ErrNoValue is returned for placeholders such as "---" or an empty string
+var ErrNoValue = errors.New("no value")
+
+// Multipliers of the Japanese unit prefixes. A small prefix may come before a large one and multiplies it,
+// e.g. 百万 (10^6) or 千万 (10^7), as written on the sites.
+var (
+	smallPrefixes = []unitPrefix{{"十", 10}, {"百", 100}, {"千", 1_000}}
+	largePrefixes = []unitPrefix{{"万", 10_000}, {"億", 100_000_000}, {"兆", 1_000_000_000_000}}
+)
+
+type unitPrefix struct {
+	prefix     string
+	multiplier int64
+}
+
+// Remove the spaces, thousands separators and the unit from a value, with full-width digits and signs made ASCII
+func clean(s, unit string) string {
+	s = strings.TrimSpace(width.Narrow.String(s))
+	s = strings.ReplaceAll(s, ",", "")
+	s = strings.NewReplacer("\u00a0", "", "\u3000", "", " ", "").Replace(s)
+	return strings.TrimSuffix(s, unit)
+}
+
+func isPlaceholder(s string) bool {
+	return s == "" || strings.Trim(s, "-－―") == ""
+}
+
+// Split the unit prefixes off a number, e.g. "307216百万" -> "307216", 1000000
+func splitPrefix(s string) (string, int64) {
+	multiplier := int64(1)
+	for _, u := range largePrefixes {
+		if strings.HasSuffix(s, u.prefix) {
+			s, multiplier = strings.TrimSuffix(s, u.prefix), u.multiplier
+			break
+		}
+	}
+	for _, u := range smallPrefixes {
+		if strings.HasSuffix(s, u.prefix) {
+			s, multiplier = strings.TrimSuffix(s, u.prefix), multiplier*u.multiplier
+			break
+		}
+	}
+	return s, multiplier
+}
+
+// Parse an integer amount with an optional unit prefix.
+// Fractions are allowed when the prefix makes the result whole, e.g. "1.5千" -> 1500.
+func parseAmount(s, unit string) (int64, error) {
+	value := clean(s, unit)
+	if isPlaceholder(value) {
+		return 0, ErrNoValue
+	}
+
+	number, multiplier := splitPrefix(value)
+	if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+		return n * multiplier, nil
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return int64(math.Round(f * float64(multiplier))), nil
+}
+
+// Yen parses an amount of money, e.g. "307,216百万円" -> 307216000000
+func Yen(s string) (int64, error) {
+	return parseAmount(s, "円")
+}
+
+// Shares parses a number of shares, e.g. "164,993千株" -> 164993000
+func Shares(s string) (int64, error) {
+	return parseAmount(s, "株")
+}
+
+// Count parses a number of people, e.g. "2,190人" -> 2190
+func Count(s string) (int64, error) {
+	return parseAmount(s, "人")
+}
+
+// Price parses a share price, e.g. "1,862.5円" -> 1862.5
+func Price(s string) (float64, error) {
+	value := clean(s, "円")
+	if isPlaceholder(value) {
+		return 0, ErrNoValue
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid price %q", s)
+	}
+	return f, nil
+}
+
+// Age parses an average age, e.g. "36.0歳" -> 36
+func Age(s string) (float64, error) {
+	value := clean(s, "歳")
+	if isPlaceholder(value) {
+		return 0, ErrNoValue
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return f, nil
+}
+
+// Month parses a month such as the settlement month, e.g. "6月" -> 6
+func Month(s string) (int, error) {
+	value := clean(s, "月")
+	if isPlaceholder(value) {
+		return 0, ErrNoValue
+	}
+
+	m, err := strconv.Atoi(value)
+	if err != nil || m < 1 || m > 12 {
+		return 0, fmt.Errorf("invalid month %q", s)
+	}
+	return m, nil
+}
+
+// Change parses a price change with its percentage, e.g. "+21(+1.14%)" -> 21, 1.14.
+// The percentage is optional; "-3.5" -> -3.5, 0.
+func Change(s string) (change float64, percent float64, err error) {
+	value := clean(s, "")
+	value = strings.NewReplacer("（", "(", "）", ")", "％", "%", "＋", "+", "−", "-", "－", "-").Replace(value)
+	if isPlaceholder(value) {
+		return 0, 0, ErrNoValue
+	}
+
+	amount, rest, hasPercent := strings.Cut(value, "(")
+	amount = strings.TrimSuffix(amount, "円")
+	if change, err = strconv.ParseFloat(amount, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid change %q", s)
+	}
+
+	if hasPercent {
+		rest = strings.TrimSuffix(strings.TrimSuffix(rest, ")"), "%")
+		if percent, err = strconv.ParseFloat(rest, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid change %q", s)
+		}
+	}
+	return change, percent, nil
+}
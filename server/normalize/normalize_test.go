@@ -0,0 +1,152 @@
+package normalize
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAmounts(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(string) (int64, error)
+		in    string
+		want  int64
+		err   error
+	}{
+		{"Yen", Yen, "307,216百万円", 307_216_000_000, nil},
+		{"Yen", Yen, "1.5億円", 150_000_000, nil},
+		{"Yen", Yen, "2,000千円", 2_000_000, nil},
+		{"Yen", Yen, "3兆円", 3_000_000_000_000, nil},
+		{"Yen", Yen, "１２，３４５円", 12_345, nil},
+		{"Yen", Yen, " 500万円 ", 5_000_000, nil},
+		{"Yen", Yen, "1千万円", 10_000_000, nil},
+		{"Yen", Yen, "3,500千万円", 35_000_000_000, nil},
+		{"Yen", Yen, "2.5千万円", 25_000_000, nil},
+		{"Yen", Yen, "5百億円", 50_000_000_000, nil},
+		{"Yen", Yen, "1千億円", 100_000_000_000, nil},
+		{"Yen", Yen, "12十万円", 1_200_000, nil},
+		{"Yen", Yen, "300百円", 30_000, nil},
+		{"Yen", Yen, "---", 0, ErrNoValue},
+		{"Yen", Yen, "-", 0, ErrNoValue},
+		{"Yen", Yen, "", 0, ErrNoValue},
+		{"Shares", Shares, "164,993千株", 164_993_000, nil},
+		{"Shares", Shares, "1.5千株", 1_500, nil},
+		{"Shares", Shares, "１００株", 100, nil},
+		{"Shares", Shares, "－", 0, ErrNoValue},
+		{"Count", Count, "2,190人", 2_190, nil},
+		{"Count", Count, "２１９０人", 2_190, nil},
+		{"Count", Count, "---人", 0, ErrNoValue},
+	}
+	for _, tt := range tests {
+		got, err := tt.parse(tt.in)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s(%q) error = %v, want %v", tt.name, tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s(%q) = %d, %v, want %d", tt.name, tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestInvalidAmounts(t *testing.T) {
+	for _, in := range []string{"abc円", "12x百万円", "百万円", "1万千円", "1千千円", "1億万円"} {
+		if _, err := Yen(in); err == nil || errors.Is(err, ErrNoValue) {
+			t.Errorf("Yen(%q) error = %v, want an invalid amount", in, err)
+		}
+	}
+}
+
+func TestFloats(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(string) (float64, error)
+		in    string
+		want  float64
+		err   error
+	}{
+		{"Price", Price, "1,862.5円", 1862.5, nil},
+		{"Price", Price, "１，８６２．５円", 1862.5, nil},
+		{"Price", Price, "2,318", 2318, nil},
+		{"Price", Price, "---", 0, ErrNoValue},
+		{"Price", Price, "", 0, ErrNoValue},
+		{"Age", Age, "36.0歳", 36, nil},
+		{"Age", Age, "４１．２歳", 41.2, nil},
+		{"Age", Age, "-", 0, ErrNoValue},
+	}
+	for _, tt := range tests {
+		got, err := tt.parse(tt.in)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s(%q) error = %v, want %v", tt.name, tt.in, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s(%q) = %v, %v, want %v", tt.name, tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestMonth(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{"6月", 6, false},
+		{"12月", 12, false},
+		{"３月", 3, false},
+		{"0月", 0, true},
+		{"13月", 0, true},
+		{"---", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := Month(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Month(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := Month("---"); !errors.Is(err, ErrNoValue) {
+		t.Errorf("Month(%q) error = %v, want %v", "---", err, ErrNoValue)
+	}
+}
+
+func TestChange(t *testing.T) {
+	tests := []struct {
+		in            string
+		change, pct   float64
+		err           error
+		wantSomeError bool
+	}{
+		{in: "+21(+1.14%)", change: 21, pct: 1.14},
+		{in: "-35(-1.84%)", change: -35, pct: -1.84},
+		{in: "0(0.00%)", change: 0, pct: 0},
+		{in: "+1,200.5円(+3.20%)", change: 1200.5, pct: 3.2},
+		{in: "＋２１（＋１．１４％）", change: 21, pct: 1.14},
+		{in: "−35（−1.84％）", change: -35, pct: -1.84},
+		{in: "-3.5", change: -3.5},
+		{in: "---", err: ErrNoValue},
+		{in: "-", err: ErrNoValue},
+		{in: "", err: ErrNoValue},
+		{in: "+21(abc%)", wantSomeError: true},
+	}
+	for _, tt := range tests {
+		change, pct, err := Change(tt.in)
+		switch {
+		case tt.err != nil:
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Change(%q) error = %v, want %v", tt.in, err, tt.err)
+			}
+		case tt.wantSomeError:
+			if err == nil {
+				t.Errorf("Change(%q) = %v, %v, want an error", tt.in, change, pct)
+			}
+		case err != nil || change != tt.change || pct != tt.pct:
+			t.Errorf("Change(%q) = %v, %v, %v, want %v, %v", tt.in, change, pct, err, tt.change, tt.pct)
+		}
+	}
+}
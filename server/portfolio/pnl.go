@@ -0,0 +1,72 @@
+// Package portfolio computes positions and profit and loss from trades,
+// using the moving average cost method.
+package portfolio
+
+import (
+	"fmt"
+	"server/models"
+)
+
+// ErrOversold is returned when a sell exceeds the shares held at that time
+type ErrOversold struct {
+	StockCode int
+	Held      int
+	Sold      int
+}
+
+func (e *ErrOversold) Error() string {
+	return fmt.Sprintf("cannot sell %d shares of %d: only %d held", e.Sold, e.StockCode, e.Held)
+}
+
+// Position is the state of one stock after replaying its trades
+type Position struct {
+	StockCode   int
+	Quantity    int
+	CostBasis   float64 // Cost of the shares still held, fees included
+	RealizedPnL float64 // Proceeds of the sells minus their cost, fees included
+}
+
+// AverageCost is the cost per share held
+func (p *Position) AverageCost() float64 {
+	if p.Quantity == 0 {
+		return 0
+	}
+	return p.CostBasis / float64(p.Quantity)
+}
+
+// Positions replays the trades, which must be in chronological order, and returns the position per stock code
+func Positions(trades []models.Trade) (map[int]*Position, error) {
+	positions := map[int]*Position{}
+	for _, trade := range trades {
+		position, ok := positions[trade.StockCode]
+		if !ok {
+			position = &Position{StockCode: trade.StockCode}
+			positions[trade.StockCode] = position
+		}
+
+		amount := float64(trade.Quantity) * trade.Price
+		switch trade.Side {
+		case models.Buy:
+			position.Quantity += trade.Quantity
+			position.CostBasis += amount + trade.Fees
+		case models.Sell:
+			if trade.Quantity > position.Quantity {
+				return nil, &ErrOversold{StockCode: trade.StockCode, Held: position.Quantity, Sold: trade.Quantity}
+			}
+			cost := position.AverageCost() * float64(trade.Quantity)
+			position.RealizedPnL += amount - trade.Fees - cost
+			position.Quantity -= trade.Quantity
+			position.CostBasis -= cost
+			if position.Quantity == 0 {
+				position.CostBasis = 0
+			}
+		}
+	}
+	return positions, nil
+}
+
+// Unrealized returns the market value of the position at price and its profit over the cost basis
+func Unrealized(p *Position, price float64) (marketValue float64, pnl float64) {
+	marketValue = float64(p.Quantity) * price
+	return marketValue, marketValue - p.CostBasis
+}
@@ -0,0 +1,102 @@
+package portfolio_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"server/models"
+	"server/portfolio"
+)
+
+func buy(code, quantity int, price, fees float64) models.Trade {
+	return models.Trade{StockCode: code, Side: models.Buy, Quantity: quantity, Price: price, Fees: fees}
+}
+
+func sell(code, quantity int, price, fees float64) models.Trade {
+	return models.Trade{StockCode: code, Side: models.Sell, Quantity: quantity, Price: price, Fees: fees}
+}
+
+func TestPositions(t *testing.T) {
+	tests := []struct {
+		name   string
+		trades []models.Trade
+		want   map[int]portfolio.Position
+	}{
+		{
+			name:   "buys average the cost with the fees",
+			trades: []models.Trade{buy(4385, 100, 1000, 0), buy(4385, 100, 1200, 200)},
+			want:   map[int]portfolio.Position{4385: {StockCode: 4385, Quantity: 200, CostBasis: 220200}},
+		},
+		{
+			// Average cost 1101, so the sell realizes 65000 - 100 - 55050
+			name:   "partial sells realize against the average cost",
+			trades: []models.Trade{buy(4385, 100, 1000, 0), buy(4385, 100, 1200, 200), sell(4385, 50, 1300, 100)},
+			want:   map[int]portfolio.Position{4385: {StockCode: 4385, Quantity: 150, CostBasis: 165150, RealizedPnL: 9850}},
+		},
+		{
+			// The rest realizes 150000 - 165150
+			name: "selling everything clears the cost basis",
+			trades: []models.Trade{
+				buy(4385, 100, 1000, 0), buy(4385, 100, 1200, 200), sell(4385, 50, 1300, 100), sell(4385, 150, 1000, 0),
+			},
+			want: map[int]portfolio.Position{4385: {StockCode: 4385, RealizedPnL: -5300}},
+		},
+		{
+			name:   "a buy after selling out starts a new average",
+			trades: []models.Trade{buy(4385, 100, 1000, 0), sell(4385, 100, 1100, 0), buy(4385, 100, 900, 0)},
+			want:   map[int]portfolio.Position{4385: {StockCode: 4385, Quantity: 100, CostBasis: 90000, RealizedPnL: 10000}},
+		},
+		{
+			name:   "stocks are replayed apart",
+			trades: []models.Trade{buy(4385, 100, 1000, 0), buy(7203, 10, 3000, 0), sell(7203, 5, 3200, 0)},
+			want: map[int]portfolio.Position{
+				4385: {StockCode: 4385, Quantity: 100, CostBasis: 100000},
+				7203: {StockCode: 7203, Quantity: 5, CostBasis: 15000, RealizedPnL: 1000},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			positions, err := portfolio.Positions(tt.trades)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[int]portfolio.Position{}
+			for code, position := range positions {
+				got[code] = *position
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Positions = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPositionsOversold(t *testing.T) {
+	tests := []struct {
+		name   string
+		trades []models.Trade
+		want   portfolio.ErrOversold
+	}{
+		{"sell without a buy", []models.Trade{sell(4385, 100, 1000, 0)}, portfolio.ErrOversold{StockCode: 4385, Held: 0, Sold: 100}},
+		{"sell over the shares held", []models.Trade{buy(4385, 100, 1000, 0), sell(4385, 150, 1000, 0)}, portfolio.ErrOversold{StockCode: 4385, Held: 100, Sold: 150}},
+		{
+			"sell over the shares left",
+			[]models.Trade{buy(4385, 100, 1000, 0), sell(4385, 60, 1000, 0), sell(4385, 60, 1000, 0)},
+			portfolio.ErrOversold{StockCode: 4385, Held: 40, Sold: 60},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := portfolio.Positions(tt.trades)
+			var oversold *portfolio.ErrOversold
+			if !errors.As(err, &oversold) {
+				t.Fatalf("error = %v, want ErrOversold", err)
+			}
+			if *oversold != tt.want {
+				t.Errorf("error = %+v, want %+v", *oversold, tt.want)
+			}
+		})
+	}
+}
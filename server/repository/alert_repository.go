@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"server/db"
+	"server/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetAlertRules returns all rules, or the rules of one stock if code is not zero
+func GetAlertRules(code int) ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	query := db.DB.Order("stock_code, id")
+	if code != 0 {
+		query = query.Where("stock_code = ?", code)
+	}
+	if err := query.Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func GetEnabledAlertRules(code int) ([]models.AlertRule, error) {
+	var rules []models.AlertRule
+	if err := db.DB.Where("stock_code = ? AND enabled = ?", code, true).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func GetAlertRuleByID(id string) (*models.AlertRule, error) {
+	var rule models.AlertRule
+	if err := db.DB.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func CreateAlertRule(rule *models.AlertRule) error {
+	return db.DB.Create(rule).Error
+}
+
+func UpdateAlertRule(rule *models.AlertRule) error {
+	return db.DB.Save(rule).Error
+}
+
+func DeleteAlertRule(id string) error {
+	return db.DB.Delete(&models.AlertRule{}, id).Error
+}
+
+// TriggerAlertRule records the trigger of the rule on the trading date of at and creates the event.
+// It returns false without creating the event if the rule already triggered on that date.
+func TriggerAlertRule(rule *models.AlertRule, event *models.Event, at time.Time) (bool, error) {
+	created := false
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		trigger := models.AlertTrigger{RuleID: rule.ID, TradingDate: TradingDate(at)}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&trigger)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&trigger).Update("event_id", event.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(rule).Update("last_triggered_at", at).Error; err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	if err != nil || !created {
+		return false, err
+	}
+	rule.LastTriggeredAt = &at
+	return true, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"server/db"
+	"server/models"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// Articles whose links are replaced by one statement, within the SQLite limit of the bound variables
+const articleStockBatch = 500
+
+// ReplaceArticleStocks replaces the stocks linked to each article with its links, in one transaction
+func ReplaceArticleStocks(articleType models.ArticleType, links map[uint][]models.ArticleStock) error {
+	if len(links) == 0 {
+		return nil
+	}
+	articleIDs := make([]uint, 0, len(links))
+	for id := range links {
+		articleIDs = append(articleIDs, id)
+	}
+	sort.Slice(articleIDs, func(i, j int) bool { return articleIDs[i] < articleIDs[j] })
+
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(articleIDs); start += articleStockBatch {
+			ids := articleIDs[start:min(start+articleStockBatch, len(articleIDs))]
+			if err := tx.Where("article_type = ? AND article_id IN ?", articleType, ids).Delete(&models.ArticleStock{}).Error; err != nil {
+				return err
+			}
+
+			var batch []models.ArticleStock
+			for _, id := range ids {
+				for _, link := range links[id] {
+					link.ArticleType = articleType
+					link.ArticleID = id
+					batch = append(batch, link)
+				}
+			}
+			if len(batch) == 0 {
+				continue
+			}
+			if err := tx.CreateInBatches(&batch, articleStockBatch).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetArticleStocks returns the stocks linked to each of the articles, in order of their codes
+func GetArticleStocks(articleType models.ArticleType, articleIDs []uint) (map[uint][]models.ArticleStock, error) {
+	stocks := map[uint][]models.ArticleStock{}
+	if len(articleIDs) == 0 {
+		return stocks, nil
+	}
+
+	var links []models.ArticleStock
+	if err := db.DB.Where("article_type = ? AND article_id IN ?", articleType, articleIDs).
+		Order("article_id, stock_code").Find(&links).Error; err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		stocks[link.ArticleID] = append(stocks[link.ArticleID], link)
+	}
+	return stocks, nil
+}
+
+// GetStockNewsArticles returns the latest news articles linked to a stock, like GetAllNewsArticles
+func GetStockNewsArticles(code, limit int) ([]models.NewsArticle, error) {
+	var articles []models.NewsArticle
+	err := db.DB.Joins("JOIN article_stocks ON article_stocks.article_id = news_articles.id AND article_stocks.article_type = ?", models.NewsArticleType).
+		Where("article_stocks.stock_code = ?", code).
+		Order("news_articles.published_at IS NULL, news_articles.published_at DESC, news_articles.id DESC").
+		Limit(limit).Find(&articles).Error
+	return articles, err
+}
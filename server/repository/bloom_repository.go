@@ -3,51 +3,91 @@ package repository
 import (
 	"log"
 	"server/models"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// Save the new articles and mark the saved ones as seen again,
+// filling the articles with their stored rows
 func SaveNewsArticles(db *gorm.DB, articles []models.NewsArticle) error {
-	for _, article := range articles {
-		// Execude previous saved news
+	now := time.Now()
+	for i := range articles {
+		// Check existance, without logging the new ones as errors
 		var existing models.NewsArticle
+		result := db.Where("link = ?", articles[i].Link).Limit(1).Find(&existing)
+		if result.Error != nil {
+			// Unpredicted error
+			log.Println("Error checking existing news:", result.Error)
+			return result.Error
+		}
 
-		// Check existance
-		err := db.Where("link = ?", article.Link).First(&existing).Error
-
-		if err != nil {
+		if result.RowsAffected == 0 {
 			// Add new data
-			if err == gorm.ErrRecordNotFound {
-				if saveErr := db.Create(&article).Error; saveErr != nil {
-					log.Println("Failed to save news:", saveErr)
-					return saveErr
-				}
-			} else {
-				// Unpredicted error
-				log.Println("Error checking existing news:", err)
-				return err
+			articles[i].FirstSeenAt = &now
+			articles[i].LastSeenAt = &now
+			if saveErr := db.Create(&articles[i]).Error; saveErr != nil {
+				log.Println("Failed to save news:", saveErr)
+				return saveErr
 			}
+			continue
+		}
+
+		// Rows saved before the first seen time was recorded take the first time they are seen again
+		updates := map[string]interface{}{"last_seen_at": now}
+		if existing.FirstSeenAt == nil {
+			updates["first_seen_at"] = now
 		}
+		if err := db.Model(&existing).Updates(updates).Error; err != nil {
+			log.Println("Failed to save news:", err)
+			return err
+		}
+		articles[i] = existing
 	}
 	return nil
 }
 
-// Get all saved news
-func GetAllNewsArticles(db *gorm.DB) ([]models.NewsArticle, error) {
+// Restrict a query to the articles of a source, or to all of them if source is empty
+func whereNewsSource(query *gorm.DB, source string) *gorm.DB {
+	if source == "" {
+		return query
+	}
+	return query.Where("source = ?", source)
+}
+
+// Get all saved news of a source, the latest published first and the ones without a publish time last
+func GetAllNewsArticles(db *gorm.DB, source string) ([]models.NewsArticle, error) {
 	var articles []models.NewsArticle
-	err := db.Find(&articles).Error
+	err := whereNewsSource(db, source).
+		Order("published_at IS NULL, published_at DESC, id DESC").Find(&articles).Error
 	return articles, err
 }
 
-// Search articles
-func SearchNewsArticles(db *gorm.DB, query string) ([]models.NewsArticle, error) {
+// Get the articles of a source whose page has not been read yet, the latest found first
+func GetUnenrichedNewsArticles(db *gorm.DB, source string, limit int) ([]models.NewsArticle, error) {
+	var articles []models.NewsArticle
+	err := whereNewsSource(db, source).
+		Where("enriched_at IS NULL").Order("id DESC").Limit(limit).Find(&articles).Error
+	return articles, err
+}
+
+// Save the metadata read from the page of an article
+func SaveNewsArticleDetails(db *gorm.DB, article *models.NewsArticle) error {
+	return db.Model(article).
+		Select("description", "published_at", "author", "section", "image_url", "enriched_at").
+		Updates(article).Error
+}
+
+// Search articles of a source, or of all of them if source is empty
+func SearchNewsArticles(db *gorm.DB, query, source string) ([]models.NewsArticle, error) {
 	var articles []models.NewsArticle
 
 	// DEBUG
 	// fmt.Println("Query: ", query)
 
 	// Query
-	err := db.Where("title LIKE ?", "%"+query+"%").
+	err := whereNewsSource(db, source).
+		Where("title LIKE ?", "%"+query+"%").
 		Find(&articles).Error
 
 	return articles, err
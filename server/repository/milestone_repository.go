@@ -3,6 +3,8 @@ package repository
 import (
 	"server/db"
 	"server/models"
+
+	"gorm.io/gorm"
 )
 
 func GetAllMilestones() ([]models.Milestone, error) {
@@ -20,9 +22,12 @@ func CreateMilestone(item *models.Milestone) error {
 	return nil
 }
 
+// DeleteMilestoneByID deletes the milestone with its links to the stocks
 func DeleteMilestoneByID(id string) error {
-	if err := db.DB.Delete(&models.Milestone{}, id).Error; err != nil {
-		return err
-	}
-	return nil
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("article_type = ? AND article_id = ?", models.MilestoneType, id).Delete(&models.ArticleStock{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Milestone{}, id).Error
+	})
 }
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"server/db"
+	"server/models"
+)
+
+// Configure the default sources when there are none yet,
+// and mark the articles saved before there were several sources as Bloomberg ones
+func InitNewsSources(defaults []models.NewsSource) error {
+	var count int64
+	if err := db.DB.Model(&models.NewsSource{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 && len(defaults) > 0 {
+		if err := db.DB.Create(&defaults).Error; err != nil {
+			return err
+		}
+	}
+	return db.DB.Model(&models.NewsArticle{}).Where("source = ''").Update("source", "bloomberg").Error
+}
+
+func GetNewsSources() ([]models.NewsSource, error) {
+	var sources []models.NewsSource
+	if err := db.DB.Order("id").Find(&sources).Error; err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// GetEnabledNewsSources returns the enabled sources, or the one named name if it is not empty
+func GetEnabledNewsSources(name string) ([]models.NewsSource, error) {
+	var sources []models.NewsSource
+	query := db.DB.Where("enabled = ?", true).Order("id")
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	if err := query.Find(&sources).Error; err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+func GetNewsSourceByID(id string) (*models.NewsSource, error) {
+	var source models.NewsSource
+	if err := db.DB.First(&source, id).Error; err != nil {
+		return nil, err
+	}
+	return &source, nil
+}
+
+func CreateNewsSource(source *models.NewsSource) error {
+	return db.DB.Create(source).Error
+}
+
+func UpdateNewsSource(source *models.NewsSource) error {
+	return db.DB.Save(source).Error
+}
+
+func DeleteNewsSource(id string) error {
+	return db.DB.Delete(&models.NewsSource{}, id).Error
+}
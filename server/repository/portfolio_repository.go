@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"server/db"
+	"server/models"
+	"server/portfolio"
+
+	"gorm.io/gorm"
+)
+
+func GetAllPortfolios() ([]models.Portfolio, error) {
+	var items []models.Portfolio
+	if err := db.DB.Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func GetPortfolioByID(id string) (*models.Portfolio, error) {
+	var item models.Portfolio
+	if err := db.DB.First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func CreatePortfolio(item *models.Portfolio) error {
+	return db.DB.Create(item).Error
+}
+
+func UpdatePortfolio(item *models.Portfolio) error {
+	return db.DB.Save(item).Error
+}
+
+// DeletePortfolio removes the portfolio with its trades and holdings
+func DeletePortfolio(id uint) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("portfolio_id = ?", id).Delete(&models.Trade{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("portfolio_id = ?", id).Delete(&models.Holding{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Portfolio{}, id).Error
+	})
+}
+
+// GetTrades returns the trades of a portfolio in chronological order
+func GetTrades(portfolioID uint) ([]models.Trade, error) {
+	return getTrades(db.DB, portfolioID)
+}
+
+func getTrades(tx *gorm.DB, portfolioID uint) ([]models.Trade, error) {
+	var trades []models.Trade
+	err := tx.Where("portfolio_id = ?", portfolioID).Order("traded_at, id").Find(&trades).Error
+	return trades, err
+}
+
+func GetHoldings(portfolioID uint) ([]models.Holding, error) {
+	var holdings []models.Holding
+	err := db.DB.Preload("Stock").Where("portfolio_id = ?", portfolioID).Order("stock_code").Find(&holdings).Error
+	return holdings, err
+}
+
+// CreateTrade stores the trade and updates the holdings.
+// Nothing is stored if the trade would sell more shares than held.
+func CreateTrade(trade *models.Trade) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(trade).Error; err != nil {
+			return err
+		}
+		return rebuildHoldings(tx, trade.PortfolioID)
+	})
+}
+
+// DeleteTrade removes the trade and updates the holdings.
+// Nothing is removed if a later sell would exceed the shares held without it.
+func DeleteTrade(portfolioID, tradeID uint) error {
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("portfolio_id = ?", portfolioID).Delete(&models.Trade{}, tradeID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return rebuildHoldings(tx, portfolioID)
+	})
+}
+
+// Replay the trades of the portfolio and replace its holdings
+func rebuildHoldings(tx *gorm.DB, portfolioID uint) error {
+	trades, err := getTrades(tx, portfolioID)
+	if err != nil {
+		return err
+	}
+
+	positions, err := portfolio.Positions(trades)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Where("portfolio_id = ?", portfolioID).Delete(&models.Holding{}).Error; err != nil {
+		return err
+	}
+	for _, position := range positions {
+		holding := models.Holding{
+			PortfolioID: portfolioID,
+			StockCode:   position.StockCode,
+			Quantity:    position.Quantity,
+			AverageCost: position.AverageCost(),
+			CostBasis:   position.CostBasis,
+			RealizedPnL: position.RealizedPnL,
+		}
+		if err := tx.Create(&holding).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
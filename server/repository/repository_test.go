@@ -0,0 +1,36 @@
+package repository_test
+
+import (
+	"strings"
+	"testing"
+
+	"server/db"
+	"server/stockmaster"
+)
+
+// Open an empty in-memory database of the test as db.DB
+func openTestDB(t *testing.T) {
+	t.Helper()
+	database, err := db.Open("file:" + strings.ReplaceAll(t.Name(), "/", "_") + "?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.DB = database
+}
+
+// A stocks file with a row for each of the rows, given as the name of the stock and its code
+func stocksFile(rows ...[2]string) *strings.Reader {
+	lines := []string{strings.Join(stockmaster.StockColumns, ",")}
+	for _, row := range rows {
+		lines = append(lines, strings.Join([]string{
+			row[1], "東証プライム", row[0], "1000", row[0], "", "水産・農林業", "---", "3月", "1000百万円", "---", "---",
+			"東証プライム", "1949年5月16日", "100株",
+		}, ","))
+	}
+	return strings.NewReader(strings.Join(lines, "\n") + "\n")
+}
+
+// A stock details file without rows
+func emptyStockDetailsFile() *strings.Reader {
+	return strings.NewReader(strings.Join(stockmaster.StockDetailColumns, ",") + "\n")
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"server/db"
+	"server/models"
+)
+
+func CreateScrapeRun(run *models.ScrapeRun) error {
+	return db.DB.Create(run).Error
+}
+
+// GetScrapeSources returns the names of all sources that have been scraped
+func GetScrapeSources() ([]string, error) {
+	var sources []string
+	err := db.DB.Model(&models.ScrapeRun{}).Distinct().Order("source").Pluck("source", &sources).Error
+	return sources, err
+}
+
+// GetRecentScrapeRuns returns the latest runs of a source, newest first
+func GetRecentScrapeRuns(source string, limit int) ([]models.ScrapeRun, error) {
+	var runs []models.ScrapeRun
+	err := db.DB.Where("source = ?", source).Order("started_at DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// PruneScrapeRuns deletes all but the latest keep runs of each source, returning the number of deleted runs
+func PruneScrapeRuns(keep int) (int64, error) {
+	result := db.DB.Exec(`DELETE FROM scrape_runs WHERE id IN (
+		SELECT id FROM (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY source ORDER BY started_at DESC, id DESC) AS n FROM scrape_runs
+		) WHERE n > ?
+	)`, keep)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"server/db"
+	"server/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// StockDisclosureFilter selects a page of the disclosures of a stock
+type StockDisclosureFilter struct {
+	StockCode int
+	Since     *time.Time // Published at or after, if not nil
+	Sources   []string   // e.g. 適時開示 and PR TIMES, all of them if empty
+	Limit     int
+	Offset    int
+}
+
+// GetStockDisclosureLinks returns the links of the saved disclosures of a stock
+func GetStockDisclosureLinks(code int) (map[string]bool, error) {
+	var links []string
+	if err := db.DB.Model(&models.StockDisclosure{}).Where("stock_code = ?", code).Pluck("link", &links).Error; err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(links))
+	for _, link := range links {
+		known[link] = true
+	}
+	return known, nil
+}
+
+// Disclosures inserted by one statement, within the SQLite limit of the bound variables
+const stockDisclosureBatch = 500
+
+// SaveStockDisclosures inserts the disclosures whose link is not saved yet
+func SaveStockDisclosures(disclosures []models.StockDisclosure) error {
+	if len(disclosures) == 0 {
+		return nil
+	}
+	return db.DB.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "link"}}, DoNothing: true}).
+		CreateInBatches(&disclosures, stockDisclosureBatch).Error
+}
+
+// GetStockNewsCoverage returns how far back the disclosures of a stock are saved, or nil before the first fetch
+func GetStockNewsCoverage(code int) (*models.StockNewsCoverage, error) {
+	var coverage []models.StockNewsCoverage
+	if err := db.DB.Where("stock_code = ?", code).Limit(1).Find(&coverage).Error; err != nil {
+		return nil, err
+	}
+	if len(coverage) == 0 {
+		return nil, nil
+	}
+	return &coverage[0], nil
+}
+
+func SaveStockNewsCoverage(coverage *models.StockNewsCoverage) error {
+	return db.DB.Save(coverage).Error
+}
+
+// GetStockDisclosures returns a page of the disclosures, latest published first, and how many match the filter
+func GetStockDisclosures(filter StockDisclosureFilter) ([]models.StockDisclosure, int64, error) {
+	query := db.DB.Model(&models.StockDisclosure{}).Where("stock_code = ?", filter.StockCode)
+	if filter.Since != nil {
+		query = query.Where("published_at >= ?", *filter.Since)
+	}
+	if len(filter.Sources) > 0 {
+		query = query.Where("source IN ?", filter.Sources)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	disclosures := []models.StockDisclosure{}
+	err := query.Order("published_at IS NULL, published_at DESC, id").
+		Limit(filter.Limit).Offset(filter.Offset).Find(&disclosures).Error
+	return disclosures, total, err
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"io"
+	"log"
+	"server/db"
+	"server/models"
+	"time"
+)
+
+// RunStockMasterImport imports a stocks file and a stock details file as the batch of the import record,
+// saving the record with the counts of both files.
+// The stocks missing from the stocks file are delisted only if delist is true and the file is a dataset
+// no older than the latest imported one, as the stocks missing from an older one may have been listed since.
+func RunStockMasterImport(imp *models.StockMasterImport, stocks, stockDetails io.Reader, delist bool) (*ImportReport, *ImportReport, error) {
+	if delist && imp.DatasetMonth != "" {
+		latestMonth, err := LatestDatasetMonth()
+		if err != nil {
+			return nil, nil, err
+		}
+		if imp.DatasetMonth < latestMonth {
+			log.Printf("Dataset %s is older than the imported %s, not delisting the missing stocks", imp.DatasetMonth, latestMonth)
+			delist = false
+		}
+	}
+
+	if err := StartStockMasterImport(imp); err != nil {
+		return nil, nil, err
+	}
+
+	stockReport, err := ImportStocks(stocks, imp.Batch, delist)
+	if err != nil {
+		return nil, nil, FinishStockMasterImport(imp, err)
+	}
+	imp.Delisted = len(stockReport.Delisted)
+
+	stockDetailReport, err := ImportStockDetails(stockDetails, imp.Batch)
+	if err != nil {
+		return stockReport, nil, FinishStockMasterImport(imp, err, stockReport)
+	}
+
+	return stockReport, stockDetailReport, FinishStockMasterImport(imp, nil, stockReport, stockDetailReport)
+}
+
+// StartStockMasterImport saves the start of an import, whose batch the records are then upserted under
+func StartStockMasterImport(imp *models.StockMasterImport) error {
+	imp.StartedAt = time.Now()
+	return db.DB.Create(imp).Error
+}
+
+func addImportCounts(imp *models.StockMasterImport, report *ImportReport) {
+	imp.Inserted += report.Inserted
+	imp.Updated += report.Updated
+	imp.Unchanged += report.Unchanged
+	imp.Failed += report.Failed
+}
+
+// FinishStockMasterImport saves the end of an import with the counts of its reports, returning the error of the import
+func FinishStockMasterImport(imp *models.StockMasterImport, importErr error, reports ...*ImportReport) error {
+	for _, report := range reports {
+		addImportCounts(imp, report)
+	}
+	finishedAt := time.Now()
+	imp.FinishedAt = &finishedAt
+	if importErr != nil {
+		imp.Error = importErr.Error()
+	}
+	if err := db.DB.Save(imp).Error; err != nil && importErr == nil {
+		return err
+	}
+	return importErr
+}
+
+// GetStockMasterImports returns the import history, newest first
+func GetStockMasterImports() ([]models.StockMasterImport, error) {
+	var imports []models.StockMasterImport
+	if err := db.DB.Order("id DESC").Find(&imports).Error; err != nil {
+		return nil, err
+	}
+	return imports, nil
+}
+
+// LatestDatasetMonth returns the latest month of the datasets imported without an error, or "" if none
+func LatestDatasetMonth() (string, error) {
+	var latest models.StockMasterImport
+	err := db.DB.Where("dataset_month <> '' AND error = '' AND finished_at IS NOT NULL").
+		Order("dataset_month DESC").Limit(1).Find(&latest).Error
+	return latest.DatasetMonth, err
+}
+
+// GetLatestStockMasterImport returns the latest import, finished or not, or an empty record if there is none
+func GetLatestStockMasterImport() (models.StockMasterImport, error) {
+	var latest models.StockMasterImport
+	err := db.DB.Order("id DESC").Limit(1).Find(&latest).Error
+	return latest, err
+}
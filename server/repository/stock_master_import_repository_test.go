@@ -0,0 +1,65 @@
+package repository_test
+
+import (
+	"testing"
+
+	"server/db"
+	"server/models"
+	"server/repository"
+)
+
+func listedCodes(t *testing.T) []int {
+	t.Helper()
+	codes, err := repository.GetListedStockCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return codes
+}
+
+func TestStockMasterImportDelisting(t *testing.T) {
+	openTestDB(t)
+	kyokuyo, nissui, maruha := [2]string{"極洋", "1301"}, [2]string{"ニッスイ", "1332"}, [2]string{"マルハニチロ", "1333"}
+
+	steps := []struct {
+		name    string
+		month   string // Dataset month, empty for an upload
+		stocks  [][2]string
+		delist  bool
+		want    []int // Listed codes after the import
+		skipped bool  // Delisting skipped
+	}{
+		{"dataset", "202503", [][2]string{kyokuyo, nissui, maruha}, true, []int{1301, 1332, 1333}, false},
+		{"partial upload", "", [][2]string{kyokuyo}, false, []int{1301, 1332, 1333}, true},
+		{"older dataset", "202502", [][2]string{kyokuyo, nissui}, true, []int{1301, 1332, 1333}, true},
+		{"upload with delist", "", [][2]string{kyokuyo, nissui}, true, []int{1301, 1332}, false},
+		{"newer dataset relisting", "202504", [][2]string{kyokuyo, nissui, maruha}, true, []int{1301, 1332, 1333}, false},
+	}
+	for _, step := range steps {
+		imp := &models.StockMasterImport{Batch: step.name, DatasetMonth: step.month}
+		report, _, err := repository.RunStockMasterImport(imp, stocksFile(step.stocks...), emptyStockDetailsFile(), step.delist)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		got := listedCodes(t)
+		if len(got) != len(step.want) {
+			t.Fatalf("%s: listed %v, want %v", step.name, got, step.want)
+		}
+		for i := range got {
+			if got[i] != step.want[i] {
+				t.Fatalf("%s: listed %v, want %v", step.name, got, step.want)
+			}
+		}
+		if report.DelistSkipped != step.skipped {
+			t.Errorf("%s: DelistSkipped = %v, want %v", step.name, report.DelistSkipped, step.skipped)
+		}
+	}
+
+	var relisted []models.StockMasterChange
+	if err := db.DB.Where("import_batch = ? AND field = ?", "newer dataset relisting", "delisted_at").Find(&relisted).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(relisted) != 1 || relisted[0].StockCode != 1333 || relisted[0].OldValue == "" || relisted[0].NewValue != "" {
+		t.Errorf("relisting changes = %+v, want the cleared delisted date of 1333", relisted)
+	}
+}
@@ -2,95 +2,325 @@ package repository
 
 import (
 	"encoding/csv"
-	"os"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
 	"server/db"
 	"server/models"
-	"strconv"
-	"strings"
+	"server/stockmaster"
+	"time"
 
-	"gorm.io/gorm/clause"
+	"gorm.io/gorm"
 )
 
-func ImportStocks(filename string) error {
-	file, err := os.Open(filename)
-	if err != nil {
-		return err
+// ImportReport is the outcome of importing one CSV file of the stock master data
+type ImportReport struct {
+	Batch     string          `json:"batch"`
+	Inserted  int             `json:"inserted"`
+	Updated   int             `json:"updated"`
+	Unchanged int             `json:"unchanged"`
+	Failed    int             `json:"failed"`
+	Failures  []ImportFailure `json:"failures"`
+	Delisted  []int           `json:"delisted,omitempty"` // Codes missing from the stocks file
+	// Delisting was skipped because the file is older than an imported dataset
+	DelistSkipped bool `json:"delist_skipped,omitempty"`
+}
+
+// ImportFailure is a row that could not be imported
+type ImportFailure struct {
+	Line      int    `json:"line,omitempty"` // 1-based, the header is line 1; none for a crawled record
+	StockCode string `json:"stock_code"`
+	Reason    string `json:"reason"`
+}
+
+// ImportOutcome is the result of upserting a record
+type ImportOutcome int
+
+const (
+	ImportInserted ImportOutcome = iota
+	ImportUpdated
+	ImportUnchanged
+)
+
+// NewImportReport returns an empty report of the batch
+func NewImportReport(batch string) *ImportReport {
+	return &ImportReport{Batch: batch, Failures: []ImportFailure{}}
+}
+
+// Fail records a record that could not be imported
+func (r *ImportReport) Fail(line int, code string, err error) {
+	r.Failed++
+	r.Failures = append(r.Failures, ImportFailure{Line: line, StockCode: code, Reason: err.Error()})
+}
+
+// Count records the outcome of an upserted record
+func (r *ImportReport) Count(outcome ImportOutcome) {
+	switch outcome {
+	case ImportInserted:
+		r.Inserted++
+	case ImportUpdated:
+		r.Updated++
+	default:
+		r.Unchanged++
 	}
-	defer file.Close()
+}
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+// ImportStocks upserts the stocks of the CSV file, logging each changed field under the batch.
+// The file is a full snapshot, so the stocks missing from it are marked as delisted if delist is true.
+func ImportStocks(r io.Reader, batch string, delist bool) (*ImportReport, error) {
+	records, err := readMasterCSV(r, stockmaster.StockColumns)
 	if err != nil {
-		return err
-	}
-
-	for _, row := range records[1:] { // Skip header
-		stockCode, _ := strconv.Atoi(row[0])
-		settlementMonth, _ := strconv.Atoi(strings.ReplaceAll(row[8], "月", ""))
-		capital, _ := strconv.Atoi(strings.ReplaceAll(strings.ReplaceAll(row[9], "千円", "000"), ",", ""))
-		listingDate := strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(row[13], "年", "/"), "月", "/"), "日", "")
-		unitShares, _ := strconv.Atoi(strings.ReplaceAll(row[14], "株", ""))
-		stockExist := true
-		if row[3] == "---" {
-			stockExist = false
+		return nil, err
+	}
+
+	report := NewImportReport(batch)
+	present := map[int]bool{}
+	for i, row := range records[1:] { // Skip header
+		line := i + 2
+		// A failed row still tells that the code is listed
+		if code, err := stockmaster.ParseStockCode(firstColumn(row)); err == nil {
+			present[code] = true
 		}
 
-		stock := models.Stock{
-			StockCode:          stockCode,
-			MarketType:         row[1],
-			StockName:          row[2],
-			StockExist:         stockExist,
-			CompanyName:        row[4],
-			EnglishCompanyName: row[5],
-			Industry:           row[6],
-			Representative:     row[7],
-			SettlementMonth:    settlementMonth,
-			Capital:            capital,
-			Address:            row[10],
-			Phone:              row[11],
-			ListingMarket:      row[12],
-			ListingDate:        listingDate,
-			UnitShares:         unitShares,
+		record, err := stockmaster.RecordOf(records[0], row)
+		if err != nil {
+			report.Fail(line, firstColumn(row), err)
+			continue
+		}
+		stock, err := stockmaster.ParseStock(record)
+		if err != nil {
+			report.Fail(line, firstColumn(row), err)
+			continue
 		}
 
-		db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&stock)
+		outcome, err := UpsertStock(&stock, batch)
+		if err != nil {
+			report.Fail(line, firstColumn(row), err)
+			continue
+		}
+		report.Count(outcome)
 	}
 
-	return nil
+	// Do not delist the whole market because of a broken file
+	if !delist {
+		report.DelistSkipped = true
+	} else if len(present) > 0 {
+		if report.Delisted, err = syncDelistedStocks(present, batch, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, RebuildStockSearchIndex()
 }
 
-func ImportStockDetails(filename string) error {
-	file, err := os.Open(filename)
+// ImportStockDetails upserts the stock details of the CSV file, logging each changed field under the batch
+func ImportStockDetails(r io.Reader, batch string) (*ImportReport, error) {
+	records, err := readMasterCSV(r, stockmaster.StockDetailColumns)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	report := NewImportReport(batch)
+	for i, row := range records[1:] { // Skip header
+		line := i + 2
+		record, err := stockmaster.RecordOf(records[0], row)
+		if err != nil {
+			report.Fail(line, firstColumn(row), err)
+			continue
+		}
+		stockDetail, err := stockmaster.ParseStockDetail(record)
+		if err != nil {
+			report.Fail(line, firstColumn(row), err)
+			continue
+		}
+
+		outcome, err := UpsertStockDetail(&stockDetail, batch)
+		if err != nil {
+			report.Fail(line, firstColumn(row), err)
+			continue
+		}
+		report.Count(outcome)
 	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
+	return report, RebuildStockSearchIndex()
+}
+
+// UpsertStock inserts the stock, or updates its changed fields and logs them under the batch
+func UpsertStock(stock *models.Stock, batch string) (ImportOutcome, error) {
+	return upsertMasterRecord(stock, stock.StockCode, batch)
+}
+
+// UpsertStockDetail inserts the stock details, or updates its changed fields and logs them under the batch
+func UpsertStockDetail(stockDetail *models.StockDetail, batch string) (ImportOutcome, error) {
+	return upsertMasterRecord(stockDetail, stockDetail.StockCode, batch)
+}
+
+// GetListedStockCodes returns the codes of the stocks that are not delisted, in order
+func GetListedStockCodes() ([]int, error) {
+	var codes []int
+	err := db.DB.Model(&models.Stock{}).Where("delisted_at IS NULL").Order("stock_code").Pluck("stock_code", &codes).Error
+	return codes, err
+}
+
+// GetListedStocks returns the stocks that are not delisted, in order of their codes
+func GetListedStocks() ([]models.Stock, error) {
+	var stocks []models.Stock
+	err := db.DB.Where("delisted_at IS NULL").Order("stock_code").Find(&stocks).Error
+	return stocks, err
+}
+
+// GetStockMasterChanges returns the logged changes, newest first, filtered by code and batch if not empty
+func GetStockMasterChanges(code int, batch string) ([]models.StockMasterChange, error) {
+	var changes []models.StockMasterChange
+	query := db.DB.Order("id DESC")
+	if code != 0 {
+		query = query.Where("stock_code = ?", code)
+	}
+	if batch != "" {
+		query = query.Where("import_batch = ?", batch)
+	}
+	if err := query.Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Mark the stocks missing from the snapshot as delisted, and clear the delisted date of the ones back in it
+func syncDelistedStocks(present map[int]bool, batch string, at time.Time) ([]int, error) {
+	var stocks []models.Stock
+	if err := db.DB.Find(&stocks).Error; err != nil {
+		return nil, err
+	}
+
+	delisted := []int{}
+	for _, stock := range stocks {
+		switch {
+		case present[stock.StockCode] && stock.DelistedAt != nil:
+			delistedAt := *stock.DelistedAt
+			err := db.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Model(&stock).Update("delisted_at", nil).Error; err != nil {
+					return err
+				}
+				return tx.Create(&models.StockMasterChange{
+					StockCode:   stock.StockCode,
+					Field:       "delisted_at",
+					OldValue:    delistedAt.Format(time.RFC3339),
+					NewValue:    "",
+					ImportBatch: batch,
+				}).Error
+			})
+			if err != nil {
+				return nil, err
+			}
+		case !present[stock.StockCode] && stock.DelistedAt == nil:
+			err := db.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Model(&stock).Updates(map[string]interface{}{"stock_exist": false, "delisted_at": at}).Error; err != nil {
+					return err
+				}
+				if !stock.StockExist {
+					return nil
+				}
+				return tx.Create(&models.StockMasterChange{
+					StockCode:   stock.StockCode,
+					Field:       "stock_exist",
+					OldValue:    "true",
+					NewValue:    "false",
+					ImportBatch: batch,
+				}).Error
+			})
+			if err != nil {
+				return nil, err
+			}
+			delisted = append(delisted, stock.StockCode)
+		}
+	}
+	return delisted, nil
+}
+
+// Read a CSV file with a header of the columns, in any order
+func readMasterCSV(r io.Reader, columns []string) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Rows with a wrong number of columns are reported, not fatal
 	records, err := reader.ReadAll()
 	if err != nil {
-		return err
-	}
-
-	for _, row := range records[1:] { // Skip header
-		stockCode, _ := strconv.Atoi(row[0])
-		employeesSolo, _ := strconv.Atoi(row[3])
-		employeesConsolidated, _ := strconv.Atoi(strings.Replace(row[4], "---", "0", -1))
-		averageAge, _ := strconv.ParseFloat(row[5], 64)
-		averageSalary, _ := strconv.Atoi(row[6])
-
-		stockDetail := models.StockDetail{
-			StockCode:             stockCode,
-			Feature:               row[1],
-			Business:              row[2],
-			EmployeesSolo:         employeesSolo,
-			EmployeesConsolidated: employeesConsolidated,
-			AverageAge:            averageAge,
-			AverageSalary:         averageSalary,
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty file")
+	}
+	if err := stockmaster.CheckHeader(records[0], columns); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func firstColumn(row []string) string {
+	if len(row) == 0 {
+		return ""
+	}
+	return row[0]
+}
+
+// Insert the record, or update the fields that differ from the stored one and log them
+func upsertMasterRecord[T models.Stock | models.StockDetail](record *T, code int, batch string) (ImportOutcome, error) {
+	// Find rather than First, so that new codes are not logged as errors
+	var existing T
+	result := db.DB.Where("stock_code = ?", code).Limit(1).Find(&existing)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if err := db.DB.Create(record).Error; err != nil {
+			return 0, err
 		}
+		return ImportInserted, nil
+	}
 
-		db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&stockDetail)
+	updates, changes := diffMasterRecord(&existing, record, code, batch)
+	if len(changes) == 0 {
+		return ImportUnchanged, nil
 	}
 
-	return nil
+	err := db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&existing).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(&changes).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return ImportUpdated, nil
+}
+
+// Compare the plain fields of two records, skipping the keys, the associations and the delisted date
+func diffMasterRecord(existing, record interface{}, code int, batch string) (map[string]interface{}, []models.StockMasterChange) {
+	oldValue := reflect.ValueOf(existing).Elem()
+	newValue := reflect.ValueOf(record).Elem()
+
+	updates := map[string]interface{}{}
+	var changes []models.StockMasterChange
+	for i := 0; i < oldValue.NumField(); i++ {
+		field := oldValue.Type().Field(i)
+		if field.Name == "ID" || field.Name == "StockCode" || field.Type.Kind() == reflect.Struct || field.Type.Kind() == reflect.Ptr {
+			continue
+		}
+
+		before, after := oldValue.Field(i).Interface(), newValue.Field(i).Interface()
+		if before == after {
+			continue
+		}
+
+		column := db.DB.NamingStrategy.ColumnName("", field.Name)
+		updates[column] = after
+		changes = append(changes, models.StockMasterChange{
+			StockCode:   code,
+			Field:       column,
+			OldValue:    fmt.Sprint(before),
+			NewValue:    fmt.Sprint(after),
+			ImportBatch: batch,
+		})
+	}
+	return updates, changes
 }
@@ -0,0 +1,110 @@
+package repository_test
+
+import (
+	"reflect"
+	"testing"
+
+	"server/db"
+	"server/models"
+	"server/repository"
+)
+
+// A change of the log, without its ID and time
+type change struct {
+	code          int
+	field         string
+	before, after string
+}
+
+func batchChanges(t *testing.T, batch string) []change {
+	t.Helper()
+	var rows []models.StockMasterChange
+	if err := db.DB.Where("import_batch = ?", batch).Order("id").Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	var changes []change
+	for _, row := range rows {
+		changes = append(changes, change{row.StockCode, row.Field, row.OldValue, row.NewValue})
+	}
+	return changes
+}
+
+func TestImportStocksCounts(t *testing.T) {
+	openTestDB(t)
+	kyokuyo, nissui, maruha := [2]string{"極洋", "1301"}, [2]string{"ニッスイ", "1332"}, [2]string{"マルハニチロ", "1333"}
+
+	steps := []struct {
+		name    string
+		stocks  [][2]string
+		want    repository.ImportReport // Counts only
+		changes []change
+	}{
+		{"first import", [][2]string{kyokuyo, nissui}, repository.ImportReport{Inserted: 2}, nil},
+		{"same file", [][2]string{kyokuyo, nissui}, repository.ImportReport{Unchanged: 2}, nil},
+		{
+			"renamed stock", [][2]string{kyokuyo, {"日本水産", "1332"}}, repository.ImportReport{Updated: 1, Unchanged: 1},
+			[]change{{1332, "stock_name", "ニッスイ", "日本水産"}, {1332, "company_name", "ニッスイ", "日本水産"}},
+		},
+		{
+			"new and invalid rows", [][2]string{kyokuyo, {"日本水産", "1332"}, maruha, {"不明", "abcd"}},
+			repository.ImportReport{Inserted: 1, Unchanged: 2, Failed: 1}, nil,
+		},
+	}
+	for _, step := range steps {
+		report, err := repository.ImportStocks(stocksFile(step.stocks...), step.name, false)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		got := repository.ImportReport{Inserted: report.Inserted, Updated: report.Updated, Unchanged: report.Unchanged, Failed: report.Failed}
+		if !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: counts %+v, want %+v", step.name, got, step.want)
+		}
+		if changes := batchChanges(t, step.name); !reflect.DeepEqual(changes, step.changes) {
+			t.Errorf("%s: changes %v, want %v", step.name, changes, step.changes)
+		}
+	}
+}
+
+func TestUpsertStockDetail(t *testing.T) {
+	openTestDB(t)
+	if _, err := repository.UpsertStock(&models.Stock{StockCode: 4385, StockName: "メルカリ"}, "stock"); err != nil {
+		t.Fatal(err)
+	}
+
+	detail := func(employees int, age float64) *models.StockDetail {
+		return &models.StockDetail{StockCode: 4385, Feature: "フリマアプリ国内首位", EmployeesConsolidated: employees, AverageAge: age, AverageSalary: 11660000}
+	}
+	steps := []struct {
+		batch   string
+		detail  *models.StockDetail
+		want    repository.ImportOutcome
+		changes []change
+	}{
+		{"insert", detail(2000, 35.5), repository.ImportInserted, nil},
+		{"same", detail(2000, 35.5), repository.ImportUnchanged, nil},
+		{
+			"update", detail(2190, 36), repository.ImportUpdated,
+			[]change{{4385, "employees_consolidated", "2000", "2190"}, {4385, "average_age", "35.5", "36"}},
+		},
+	}
+	for _, step := range steps {
+		outcome, err := repository.UpsertStockDetail(step.detail, step.batch)
+		if err != nil {
+			t.Fatalf("%s: %v", step.batch, err)
+		}
+		if outcome != step.want {
+			t.Errorf("%s: outcome %d, want %d", step.batch, outcome, step.want)
+		}
+		if changes := batchChanges(t, step.batch); !reflect.DeepEqual(changes, step.changes) {
+			t.Errorf("%s: changes %v, want %v", step.batch, changes, step.changes)
+		}
+	}
+
+	var saved models.StockDetail
+	if err := db.DB.Where("stock_code = ?", 4385).First(&saved).Error; err != nil {
+		t.Fatal(err)
+	}
+	if saved.EmployeesConsolidated != 2190 || saved.AverageAge != 36 || saved.Feature != "フリマアプリ国内首位" {
+		t.Errorf("saved %+v, want the updated fields", saved)
+	}
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"server/db"
+	"server/models"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// Tokyo Stock Exchange time zone
+var jst = time.FixedZone("JST", 9*60*60)
+
+// TradingDate returns the JST calendar date of t at midnight
+func TradingDate(t time.Time) time.Time {
+	y, m, d := t.In(jst).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, jst)
+}
+
+// SaveStockQuote stores the snapshot, replacing any row for the same code and trading date
+func SaveStockQuote(quote *models.StockQuote) error {
+	return db.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "stock_code"}, {Name: "trading_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"stock_price", "prev_close", "price_change", "price_change_percent", "market_cap", "issued_shares",
+			"average_per", "average_pbr", "stop_high", "updated_at",
+		}),
+	}).Create(quote).Error
+}
+
+// GetStockQuoteHistory returns the snapshots of a stock between from and to (inclusive), oldest first.
+// A zero from or to leaves that side of the range open.
+func GetStockQuoteHistory(code int, from, to time.Time) ([]models.StockQuote, error) {
+	var quotes []models.StockQuote
+	query := db.DB.Where("stock_code = ?", code)
+	if !from.IsZero() {
+		query = query.Where("trading_date >= ?", TradingDate(from))
+	}
+	if !to.IsZero() {
+		query = query.Where("trading_date <= ?", TradingDate(to))
+	}
+	if err := query.Order("trading_date").Find(&quotes).Error; err != nil {
+		return nil, err
+	}
+	return quotes, nil
+}
+
+// GetLatestStockQuote returns the most recent snapshot of a stock
+func GetLatestStockQuote(code int) (*models.StockQuote, error) {
+	var quote models.StockQuote
+	if err := db.DB.Where("stock_code = ?", code).Order("trading_date DESC").First(&quote).Error; err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
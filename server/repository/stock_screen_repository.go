@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"server/db"
+	"server/models"
+	"time"
+)
+
+// StockScreen is the filter of the screener; zero values are not applied
+type StockScreen struct {
+	Industries      []string
+	Markets         []string
+	CapitalMin      int64
+	CapitalMax      int64
+	SettlementMonth int
+	SalaryMin       int
+	SalaryMax       int
+	EmployeesMin    int
+	EmployeesMax    int
+	PERMin          float64
+	PERMax          float64
+	PBRMin          float64
+	PBRMax          float64
+	IncludeDelisted bool
+
+	Sort   string // One of ScreenSortKeys
+	Desc   bool
+	Limit  int
+	Offset int
+}
+
+// ScreenedStock is a stock with its details and the latest cached quote
+type ScreenedStock struct {
+	models.Stock
+	Employees     int        `json:"employees"` // Consolidated if reported, otherwise solo
+	AverageAge    float64    `json:"average_age"`
+	AverageSalary int        `json:"average_salary"`
+	StockPrice    *float64   `json:"stock_price"`
+	AveragePER    *float64   `json:"average_per"`
+	AveragePBR    *float64   `json:"average_pbr"`
+	QuoteDate     *time.Time `json:"quote_date"`
+}
+
+// ScreenSortKeys maps the sort parameter to its column
+var ScreenSortKeys = map[string]string{
+	"code":      "s.stock_code",
+	"name":      "s.stock_name",
+	"capital":   "s.capital",
+	"salary":    "average_salary",
+	"employees": "employees",
+	"age":       "average_age",
+	"price":     "stock_price",
+	"per":       "average_per",
+	"pbr":       "average_pbr",
+}
+
+const screenEmployees = "COALESCE(NULLIF(d.employees_consolidated, 0), d.employees_solo, 0)"
+
+// ScreenStocks returns a page of the stocks matching the filter and the total count
+func ScreenStocks(filter StockScreen) ([]ScreenedStock, int64, error) {
+	query := db.DB.Table("stocks AS s").
+		Joins("LEFT JOIN stock_details AS d ON d.stock_code = s.stock_code").
+		// Latest snapshot of each stock
+		Joins("LEFT JOIN stock_quotes AS q ON q.id = (SELECT id FROM stock_quotes WHERE stock_code = s.stock_code ORDER BY trading_date DESC LIMIT 1)")
+
+	if !filter.IncludeDelisted {
+		query = query.Where("s.stock_exist = ?", true)
+	}
+	if len(filter.Industries) > 0 {
+		query = query.Where("s.industry IN ?", filter.Industries)
+	}
+	if len(filter.Markets) > 0 {
+		query = query.Where("s.listing_market IN ?", filter.Markets)
+	}
+	if filter.CapitalMin > 0 {
+		query = query.Where("s.capital >= ?", filter.CapitalMin)
+	}
+	if filter.CapitalMax > 0 {
+		query = query.Where("s.capital <= ?", filter.CapitalMax)
+	}
+	if filter.SettlementMonth > 0 {
+		query = query.Where("s.settlement_month = ?", filter.SettlementMonth)
+	}
+	if filter.SalaryMin > 0 {
+		query = query.Where("d.average_salary >= ?", filter.SalaryMin)
+	}
+	if filter.SalaryMax > 0 {
+		query = query.Where("d.average_salary > 0 AND d.average_salary <= ?", filter.SalaryMax)
+	}
+	if filter.EmployeesMin > 0 {
+		query = query.Where(screenEmployees+" >= ?", filter.EmployeesMin)
+	}
+	if filter.EmployeesMax > 0 {
+		query = query.Where(screenEmployees+" > 0 AND "+screenEmployees+" <= ?", filter.EmployeesMax)
+	}
+	// A loss making company has no PER, so it is left out of a PER range
+	if filter.PERMin > 0 {
+		query = query.Where("q.average_per >= ?", filter.PERMin)
+	}
+	if filter.PERMax > 0 {
+		query = query.Where("q.average_per > 0 AND q.average_per <= ?", filter.PERMax)
+	}
+	if filter.PBRMin > 0 {
+		query = query.Where("q.average_pbr >= ?", filter.PBRMin)
+	}
+	if filter.PBRMax > 0 {
+		query = query.Where("q.average_pbr > 0 AND q.average_pbr <= ?", filter.PBRMax)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order, ok := ScreenSortKeys[filter.Sort]
+	if !ok {
+		order = ScreenSortKeys["code"]
+	}
+	direction := " ASC"
+	if filter.Desc {
+		direction = " DESC"
+	}
+	// Stocks without the value come last in either direction
+	order = order + " IS NULL, " + order + direction + ", s.stock_code"
+
+	var stocks []ScreenedStock
+	err := query.Select("s.*, " + screenEmployees + " AS employees, d.average_age, d.average_salary, " +
+		"q.stock_price, q.average_per, q.average_pbr, q.trading_date AS quote_date").
+		Order(order).Limit(filter.Limit).Offset(filter.Offset).
+		Scan(&stocks).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return stocks, total, nil
+}
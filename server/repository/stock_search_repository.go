@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"fmt"
+	"server/db"
+	"server/models"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The trigram tokenizer matches any substring of three characters or more,
+// so Japanese names are found without word boundaries
+const createStockSearchIndex = `CREATE VIRTUAL TABLE IF NOT EXISTS stock_search USING fts5(
+	stock_code, stock_name, company_name, english_company_name, feature, business,
+	tokenize = 'trigram'
+)`
+
+// Column weights of the ranking, in the order of the index columns
+const stockSearchRank = "bm25(stock_search, 20.0, 10.0, 10.0, 10.0, 2.0, 1.0)"
+
+// Shortest term the trigram index can match
+const minStockSearchTerm = 3
+
+// Whether the FTS5 index is available; otherwise the search scans the tables
+var stockSearchFTS bool
+
+// InitStockSearchIndex creates the full-text index of the stocks and fills it from the master data.
+// SQLite has to be built with FTS5 (the sqlite_fts5 build tag), otherwise the search falls back to LIKE.
+func InitStockSearchIndex() error {
+	if err := db.DB.Exec(createStockSearchIndex).Error; err != nil {
+		stockSearchFTS = false
+		return err
+	}
+	stockSearchFTS = true
+	return RebuildStockSearchIndex()
+}
+
+// RebuildStockSearchIndex replaces the index with the current stocks and their details
+func RebuildStockSearchIndex() error {
+	if !stockSearchFTS {
+		return nil
+	}
+	// In one transaction, so that a search never sees the index emptied
+	return db.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM stock_search").Error; err != nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO stock_search (rowid, stock_code, stock_name, company_name, english_company_name, feature, business)
+			SELECT s.stock_code, s.stock_code, s.stock_name, s.company_name, s.english_company_name,
+				COALESCE(d.feature, ''), COALESCE(d.business, '')
+			FROM stocks AS s LEFT JOIN stock_details AS d ON d.stock_code = s.stock_code`).Error
+	})
+}
+
+// SearchStocks returns the stocks matching every term of the query, most relevant first
+func SearchStocks(query string, limit int) ([]models.Stock, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return []models.Stock{}, nil
+	}
+
+	useFTS := stockSearchFTS
+	for _, term := range terms {
+		if utf8.RuneCountInString(term) < minStockSearchTerm {
+			useFTS = false
+		}
+	}
+
+	if useFTS {
+		return searchStocksFTS(terms, limit)
+	}
+	return searchStocksLike(terms, limit)
+}
+
+// Search through the FTS5 index ranked by BM25
+func searchStocksFTS(terms []string, limit int) ([]models.Stock, error) {
+	// Quote each term so that FTS5 operators in the query are taken literally
+	phrases := make([]string, len(terms))
+	for i, term := range terms {
+		phrases[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+
+	var stocks []models.Stock
+	err := db.DB.Table("stock_search").
+		Select("s.*").
+		Joins("JOIN stocks AS s ON s.stock_code = stock_search.rowid").
+		Where("stock_search MATCH ?", strings.Join(phrases, " ")).
+		Order(stockSearchRank + ", s.stock_exist DESC, s.stock_code").
+		Limit(limit).
+		Scan(&stocks).Error
+	return stocks, err
+}
+
+// Search by substring for terms too short for the index, ranking name matches above description matches
+func searchStocksLike(terms []string, limit int) ([]models.Stock, error) {
+	query := db.DB.Table("stocks AS s").
+		Select("s.*").
+		Joins("LEFT JOIN stock_details AS d ON d.stock_code = s.stock_code")
+
+	names := "CAST(s.stock_code AS TEXT) || ' ' || s.stock_name || ' ' || s.company_name || ' ' || s.english_company_name"
+	descriptions := "COALESCE(d.feature, '') || ' ' || COALESCE(d.business, '')"
+	rank := make([]string, 0, len(terms))
+	args := make([]interface{}, 0, len(terms))
+	for _, term := range terms {
+		pattern := "%" + escapeLike(term) + "%"
+		query = query.Where(fmt.Sprintf("(%s LIKE ? ESCAPE '\\' OR %s LIKE ? ESCAPE '\\')", names, descriptions), pattern, pattern)
+		rank = append(rank, fmt.Sprintf("(%s LIKE ? ESCAPE '\\')", names))
+		args = append(args, pattern)
+	}
+
+	var stocks []models.Stock
+	err := query.
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "(" + strings.Join(rank, " + ") + ") DESC, s.stock_exist DESC, s.stock_code",
+			Vars:               args,
+			WithoutParentheses: true,
+		}}).
+		Limit(limit).
+		Scan(&stocks).Error
+	return stocks, err
+}
+
+// Escape the wildcards of LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
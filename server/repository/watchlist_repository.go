@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"server/db"
+	"server/models"
+
+	"gorm.io/gorm/clause"
+)
+
+func GetAllWatchlistItems() ([]models.WatchlistItem, error) {
+	var items []models.WatchlistItem
+	if err := db.DB.Preload("Stock").Order("sort_order, stock_code").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func GetWatchlistItem(code int) (*models.WatchlistItem, error) {
+	var item models.WatchlistItem
+	if err := db.DB.Preload("Stock").Where("stock_code = ?", code).First(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// CreateWatchlistItem adds the item, returning false if the stock is already watched
+func CreateWatchlistItem(item *models.WatchlistItem) (bool, error) {
+	result := db.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(item)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateWatchlistItem saves the note and the sort order of the item
+func UpdateWatchlistItem(item *models.WatchlistItem) error {
+	return db.DB.Model(item).Updates(map[string]interface{}{"note": item.Note, "sort_order": item.SortOrder}).Error
+}
+
+func DeleteWatchlistItem(code int) error {
+	return db.DB.Where("stock_code = ?", code).Delete(&models.WatchlistItem{}).Error
+}
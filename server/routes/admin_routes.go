@@ -0,0 +1,166 @@
+package routes
+
+import (
+	"log"
+	"net/http"
+	"server/entitylink"
+	"server/marketdata"
+	"server/models"
+	"server/repository"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Health of a scraped source
+const (
+	scraperOK       = "ok"
+	scraperDegraded = "degraded" // Fields are missing or most runs fail
+	scraperEmpty    = "empty"    // Pages are fetched but nothing is extracted
+	scraperFailing  = "failing"  // Every run fails
+)
+
+// Runs kept per source, the largest health window; older runs are pruned every pruneScrapeRunsEvery recorded runs
+const (
+	maxHealthWindow      = 100
+	pruneScrapeRunsEvery = 100
+)
+
+// Runs recorded since the server started
+var recordedScrapeRuns atomic.Int64
+
+type scraperHealth struct {
+	Source        string            `json:"source"`
+	Status        string            `json:"status"`
+	Flagged       bool              `json:"flagged"`
+	Runs          int               `json:"runs"`
+	Errors        int               `json:"errors"`
+	Empty         int               `json:"empty"`
+	Incomplete    int               `json:"incomplete"`
+	MissingFields []string          `json:"missing_fields"`
+	LastRun       *models.ScrapeRun `json:"last_run"`
+	LastSuccessAt *time.Time        `json:"last_success_at"`
+}
+
+// RecordScrapeRun stores the outcome of a page visit, to be installed as the run recorder
+func RecordScrapeRun(run marketdata.Run) {
+	scrapeRun := models.ScrapeRun{
+		Source:          run.Source,
+		URL:             run.URL,
+		StartedAt:       run.StartedAt,
+		DurationMs:      run.Duration.Milliseconds(),
+		HTTPStatus:      run.HTTPStatus,
+		FieldsExtracted: run.FieldsExtracted,
+		MissingFields:   strings.Join(run.MissingFields, ","),
+	}
+	if run.Err != nil {
+		scrapeRun.Error = run.Err.Error()
+	}
+
+	if err := repository.CreateScrapeRun(&scrapeRun); err != nil {
+		log.Println("Failed to save scrape run:", err)
+		return
+	}
+
+	if recordedScrapeRuns.Add(1)%pruneScrapeRunsEvery == 0 {
+		if _, err := repository.PruneScrapeRuns(maxHealthWindow); err != nil {
+			log.Println("Failed to prune scrape runs:", err)
+		}
+	}
+}
+
+// Judge the health of a source from its recent runs (newest first)
+func evaluateScraperHealth(source string, runs []models.ScrapeRun) scraperHealth {
+	health := scraperHealth{Source: source, Runs: len(runs), MissingFields: []string{}}
+	if len(runs) == 0 {
+		health.Status = scraperOK
+		return health
+	}
+	health.LastRun = &runs[0]
+
+	missing := map[string]bool{}
+	succeeded := 0
+	for _, run := range runs {
+		if run.Error != "" {
+			health.Errors++
+			continue
+		}
+		succeeded++
+		if health.LastSuccessAt == nil {
+			startedAt := run.StartedAt
+			health.LastSuccessAt = &startedAt
+		}
+		if run.FieldsExtracted == 0 {
+			health.Empty++
+		}
+		if run.MissingFields != "" {
+			health.Incomplete++
+			for _, field := range strings.Split(run.MissingFields, ",") {
+				if !missing[field] {
+					missing[field] = true
+					health.MissingFields = append(health.MissingFields, field)
+				}
+			}
+		}
+	}
+
+	switch {
+	case succeeded == 0:
+		health.Status = scraperFailing
+	case health.Empty == succeeded:
+		health.Status = scraperEmpty
+	case health.Incomplete*2 > succeeded, health.Errors*2 > len(runs):
+		health.Status = scraperDegraded
+	default:
+		health.Status = scraperOK
+	}
+	health.Flagged = health.Status != scraperOK
+
+	return health
+}
+
+// Handler for the health of the scrapers
+func getScraperHealth(c echo.Context) error {
+	window := 10
+	if v := c.QueryParam("window"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxHealthWindow {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid window"})
+		}
+		window = n
+	}
+
+	sources, err := repository.GetScrapeSources()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch scrape runs"})
+	}
+
+	report := make([]scraperHealth, 0, len(sources))
+	for _, source := range sources {
+		runs, err := repository.GetRecentScrapeRuns(source, window)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch scrape runs"})
+		}
+		report = append(report, evaluateScraperHealth(source, runs))
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
+
+// Handler to link every saved article and milestone to the stocks again, e.g. after a master import
+func relinkArticleStocks(c echo.Context) error {
+	articles, milestones, err := entitylink.RelinkAll()
+	if err != nil {
+		log.Println("Failed to link articles to stocks:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to link articles to stocks"})
+	}
+	return c.JSON(http.StatusOK, map[string]int{"news_articles": articles, "milestones": milestones})
+}
+
+func RegisterAdminRoutes(e *echo.Group) {
+	e.GET("/admin/scrapers/health", getScraperHealth)
+	e.POST("/admin/articles/link", relinkArticleStocks)
+}
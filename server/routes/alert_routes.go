@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"server/handlers"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterAlertRoutes(e *echo.Group) {
+	e.GET("/alerts", handlers.GetAlertRules)
+	e.POST("/alerts", handlers.CreateAlertRule)
+	e.PUT("/alerts/:id", handlers.UpdateAlertRule)
+	e.DELETE("/alerts/:id", handlers.DeleteAlertRule)
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Server-sent events of a long scrape, written to the response as they happen.
+// Each event has a name and the JSON of its data, e.g. "event: article\ndata: {...}\n\n".
+type eventStream struct {
+	c echo.Context
+}
+
+// Start the event stream; the status and headers are sent at once, so errors after this are events
+func newEventStream(c echo.Context) *eventStream {
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, "text/event-stream")
+	header.Set(echo.HeaderCacheControl, "no-cache")
+	header.Set(echo.HeaderConnection, "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // Not buffered by a proxy
+	c.Response().WriteHeader(http.StatusOK)
+	c.Response().Flush()
+	return &eventStream{c: c}
+}
+
+// Write an event and flush it to the client.
+// A client that is gone is noticed by the scraper through the request context, so the write error is only logged.
+func (s *eventStream) send(event string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Failed to encode %s event: %v", event, err)
+		return
+	}
+	if _, err := fmt.Fprintf(s.c.Response(), "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		log.Printf("Failed to send %s event: %v", event, err)
+		return
+	}
+	s.c.Response().Flush()
+}
+
+// End the stream with an error event for a failed scrape, like scrapeErrorResponse
+func (s *eventStream) fail(err error, message string) error {
+	status, message := scrapeError(err, message)
+	if status == 0 {
+		log.Println("Client disconnected:", s.c.Request().URL)
+		return nil
+	}
+	s.send("error", map[string]interface{}{"status": status, "error": message})
+	return nil
+}
@@ -0,0 +1,316 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"server/entitylink"
+	"server/handlers"
+	"server/models"
+	"server/news"
+	"server/repository"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// type NewsArticle struct {
+// 	Title       string `json:"title"`
+// 	Link        string `json:"link"`
+// 	Description string `json:"description"`
+// }
+
+// Source of the request, fixed by an alias route or else from the query
+func newsSourceParam(c echo.Context, source string) string {
+	if source != "" {
+		return source
+	}
+	return c.QueryParam("source")
+}
+
+// Handler to Fetch and Save News of the enabled sources, merged
+func getNews(db *gorm.DB, source string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sources, err := repository.GetEnabledNewsSources(newsSourceParam(c, source))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve news sources"})
+		}
+		if len(sources) == 0 {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "No enabled news source"})
+		}
+
+		articles, err := fetchNews(c.Request().Context(), db, sources, nil)
+		if errors.Is(err, errSaveNews) {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save news"})
+		} else if err != nil {
+			return scrapeErrorResponse(c, err, "Failed to fetch news")
+		}
+		return c.JSON(http.StatusOK, articles)
+	}
+}
+
+// Progress event of a news stream, sent when a source is fetched
+type newsSourceProgress struct {
+	Source   string `json:"source"`
+	Articles int    `json:"articles"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Handler for the news of the enabled sources as server-sent events.
+// A "source" event tells each source as it is fetched, followed by an "article" event for each of its articles
+// not sent yet, and a "summary" event ends the stream with the merged articles that getNews would answer.
+func streamNews(db *gorm.DB, source string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		sources, err := repository.GetEnabledNewsSources(newsSourceParam(c, source))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve news sources"})
+		}
+		if len(sources) == 0 {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "No enabled news source"})
+		}
+
+		stream := newEventStream(c)
+		seen := map[string]bool{}
+		articles, err := fetchNews(c.Request().Context(), db, sources, func(s models.NewsSource, articles []models.NewsArticle, err error) {
+			progress := newsSourceProgress{Source: s.Name, Articles: len(articles)}
+			if err != nil {
+				progress.Error = err.Error()
+			}
+			stream.send("source", progress)
+			for _, article := range articles {
+				if seen[article.Link] {
+					continue
+				}
+				seen[article.Link] = true
+				article.Source = s.Name
+				stream.send("article", article)
+			}
+		})
+		if errors.Is(err, errSaveNews) {
+			stream.send("error", map[string]interface{}{"status": http.StatusInternalServerError, "error": "Failed to save news"})
+			return nil
+		} else if err != nil {
+			return stream.fail(err, "Failed to fetch news")
+		}
+		stream.send("summary", map[string]interface{}{"articles": articles})
+		return nil
+	}
+}
+
+// The articles were fetched but could not be saved
+var errSaveNews = errors.New("failed to save news")
+
+// Fetch the sources at the same time, each from its own site, then save and link their articles and return them merged.
+// onSource, if not nil, is called with each source as soon as it is fetched, one at a time.
+func fetchNews(ctx context.Context, db *gorm.DB, sources []models.NewsSource, onSource func(s models.NewsSource, articles []models.NewsArticle, err error)) ([]models.NewsArticle, error) {
+	ctx, cancel := context.WithTimeout(ctx, newsSourceTimeout)
+	defer cancel()
+
+	results := make([][]models.NewsArticle, len(sources))
+	errs := make([]error, len(sources))
+	readers := map[string]news.ArticleReader{}
+	done := make(chan int, len(sources))
+	for i, s := range sources {
+		fetcher, err := news.New(s.Kind, s.URL)
+		if err != nil {
+			errs[i] = err
+			done <- i
+			continue
+		}
+		if reader, ok := fetcher.(news.ArticleReader); ok {
+			readers[s.Name] = reader
+		}
+		go func(i int) {
+			results[i], errs[i] = fetcher.Fetch(ctx)
+			done <- i
+		}(i)
+	}
+	for range sources {
+		i := <-done
+		if onSource != nil {
+			onSource(sources[i], results[i], errs[i])
+		}
+	}
+
+	// A failed source is left out, unless they all failed
+	var articles []models.NewsArticle
+	seen := map[string]bool{}
+	for i, s := range sources {
+		if errs[i] != nil {
+			log.Printf("Failed to fetch news of %s: %v", s.Name, errs[i])
+			continue
+		}
+		for _, article := range results[i] {
+			// An article found by several sources belongs to the first of them
+			if seen[article.Link] {
+				continue
+			}
+			seen[article.Link] = true
+			article.Source = s.Name
+			articles = append(articles, article)
+		}
+	}
+	if allFailed(errs) {
+		return nil, errs[0]
+	}
+
+	// Save to Database (only new articles)
+	if err := repository.SaveNewsArticles(db, articles); err != nil {
+		log.Println("Failed to save news:", err)
+		return nil, errSaveNews
+	}
+
+	// Link the articles to the stocks they mention
+	if err := entitylink.LinkNewsArticles(articles); err != nil {
+		log.Println("Failed to link news to stocks:", err)
+	}
+
+	// Read the pages of the new articles without keeping the client waiting
+	if len(readers) > 0 {
+		go enrichNews(db, readers)
+	}
+
+	// The articles of several sources are merged by their publish time, keeping the order of each site otherwise
+	if len(sources) > 1 {
+		sort.SliceStable(articles, func(i, j int) bool {
+			a, b := articles[i].PublishedAt, articles[j].PublishedAt
+			return a != nil && (b == nil || a.After(*b))
+		})
+	}
+	if articles == nil {
+		articles = []models.NewsArticle{}
+	}
+	return articles, nil
+}
+
+// Whether every source failed
+func allFailed(errs []error) bool {
+	for _, err := range errs {
+		if err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Only one reader of the article pages runs at a time, within the rate limit of the sites
+var newsEnriching sync.Mutex
+
+// Fill the description, publish time, author, section and image of the articles not read yet
+func enrichNews(db *gorm.DB, readers map[string]news.ArticleReader) {
+	if !newsEnriching.TryLock() {
+		return
+	}
+	defer newsEnriching.Unlock()
+
+	for source, reader := range readers {
+		if err := enrichNewsSource(db, source, reader); err != nil {
+			log.Printf("Failed to enrich news of %s: %v", source, err)
+		}
+	}
+}
+
+func enrichNewsSource(db *gorm.DB, source string, reader news.ArticleReader) error {
+	articles, err := repository.GetUnenrichedNewsArticles(db, source, articlePageBatch)
+	if err != nil {
+		return err
+	}
+	if len(articles) == 0 {
+		return nil
+	}
+	links := make([]string, len(articles))
+	for i, article := range articles {
+		links[i] = article.Link
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), articlePagesTimeout)
+	defer cancel()
+	details, err := reader.Articles(ctx, links)
+	if err != nil {
+		log.Println("Failed to read all the news articles:", err)
+	}
+
+	// The articles left are read after the next fetch
+	enrichedAt := time.Now()
+	var enriched []models.NewsArticle
+	for i := range articles {
+		d, ok := details[articles[i].Link]
+		if !ok {
+			continue
+		}
+		articles[i].Description = d.Description
+		articles[i].PublishedAt = d.PublishedAt
+		articles[i].Author = d.Author
+		articles[i].Section = d.Section
+		articles[i].ImageURL = d.ImageURL
+		articles[i].EnrichedAt = &enrichedAt
+		if err := repository.SaveNewsArticleDetails(db, &articles[i]); err != nil {
+			return err
+		}
+		enriched = append(enriched, articles[i])
+	}
+
+	// The descriptions may mention more stocks than the titles
+	return entitylink.LinkNewsArticles(enriched)
+}
+
+func getSavedNews(db *gorm.DB, source string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		articles, err := repository.GetAllNewsArticles(db, newsSourceParam(c, source))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve news"})
+		}
+		return c.JSON(http.StatusOK, articles)
+	}
+}
+
+func searchNewsArticles(db *gorm.DB, source string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query := c.QueryParam("q") // Parameter
+		// fmt.Println("Parameter: ", query)
+
+		// Decode Japanese
+		decodedQuery, err := url.QueryUnescape(query)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid query"})
+		}
+
+		if decodedQuery == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Search query is required"})
+		}
+
+		articles, err := repository.SearchNewsArticles(db, decodedQuery, newsSourceParam(c, source))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to search news"})
+		}
+
+		// DEBUG:
+		// fmt.Println("Results: ", articles)
+
+		return c.JSON(http.StatusOK, articles)
+	}
+}
+
+// Register News Routes, with the Bloomberg ones as the news of the "bloomberg" source
+func RegisterNewsRoutes(e *echo.Group, db *gorm.DB) {
+	e.GET("/news", getNews(db, ""))
+	e.GET("/news/stream", streamNews(db, ""))
+	e.GET("/news/saved", getSavedNews(db, ""))
+	e.GET("/news/search", searchNewsArticles(db, ""))
+
+	e.GET("/news/sources", handlers.GetNewsSources)
+	e.POST("/news/sources", handlers.CreateNewsSource)
+	e.PUT("/news/sources/:id", handlers.UpdateNewsSource)
+	e.DELETE("/news/sources/:id", handlers.DeleteNewsSource)
+
+	e.GET("/bloomberg", getNews(db, "bloomberg"))
+	e.GET("/bloomberg/stream", streamNews(db, "bloomberg"))
+	e.GET("/bloomberg/saved", getSavedNews(db, "bloomberg"))
+	e.GET("/bloomberg/search", searchNewsArticles(db, "bloomberg"))
+}
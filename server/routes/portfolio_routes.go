@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"server/handlers"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterPortfolioRoutes(e *echo.Group) {
+	e.GET("/portfolios", handlers.GetPortfolios)
+	e.GET("/portfolios/:id", handlers.GetPortfolio)
+	e.POST("/portfolios", handlers.CreatePortfolio)
+	e.PUT("/portfolios/:id", handlers.UpdatePortfolio)
+	e.DELETE("/portfolios/:id", handlers.DeletePortfolio)
+	e.GET("/portfolios/:id/holdings", handlers.GetHoldings)
+	e.GET("/portfolios/:id/trades", handlers.GetTrades)
+	e.POST("/portfolios/:id/trades", handlers.CreateTrade)
+	e.DELETE("/portfolios/:id/trades/:tradeId", handlers.DeleteTrade)
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"server/marketdata"
+	"server/repository"
+)
+
+// RefreshWatchlistQuotes returns the job that fetches every watched stock and stores the snapshots
+func RefreshWatchlistQuotes(provider marketdata.MarketDataProvider) func(context.Context) {
+	return func(ctx context.Context) {
+		items, err := repository.GetAllWatchlistItems()
+		if err != nil {
+			log.Println("Failed to load watchlist:", err)
+			return
+		}
+
+		for _, item := range items {
+			if ctx.Err() != nil {
+				return
+			}
+
+			code := strconv.Itoa(item.StockCode)
+			quoteCtx, cancel := context.WithTimeout(ctx, quoteTimeout)
+			stockData, err := provider.Quote(quoteCtx, code)
+			cancel()
+			if err != nil {
+				log.Println("Failed to refresh stock quote, CODE: ", code, err)
+				continue
+			}
+
+			storeQuote(item.StockCode, stockData)
+		}
+		log.Printf("Refreshed %d watched stocks", len(items))
+	}
+}
@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"server/marketdata"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Deadlines of the scrapers
+const (
+	quoteTimeout      = 30 * time.Second // Stock page and daily valuation page
+	newsTimeout       = 3 * time.Minute  // News pages of a stock, back to the latest saved article or a year ago
+	newsSourceTimeout = 30 * time.Second // Market news pages and feeds, fetched at the same time
+
+	stockNewsRefreshInterval = 10 * time.Minute // Saved news of a stock served without fetching the news pages again
+
+	articlePagesTimeout = 5 * time.Minute // Article pages of the new headlines of a source, read in the background
+	articlePageBatch    = 20              // Article pages of a source read after each fetch of the headlines
+)
+
+// Write the response for a failed scrape
+func scrapeErrorResponse(c echo.Context, err error, message string) error {
+	status, message := scrapeError(err, message)
+	if status == 0 {
+		// Nobody is waiting for the response any more
+		log.Println("Client disconnected:", c.Request().URL)
+		return nil
+	}
+	return c.JSON(status, map[string]string{"error": message})
+}
+
+// Status and message of a failed scrape, or no status when the client is gone
+func scrapeError(err error, message string) (int, string) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return 0, ""
+	case errors.Is(err, marketdata.ErrNotSupported):
+		return http.StatusNotImplemented, message + ": not supported by the market data provider"
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, message + ": timed out"
+	default:
+		return http.StatusBadGateway, message
+	}
+}
@@ -1,19 +1,27 @@
 package routes
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"server/models"
 	"server/repository"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
 
-var (
-	stockfile       string
-	stockDetailfile string
-)
+// Directory of the monthly datasets, e.g. stock_fundamental_202503.csv and stock_profile_202503.csv
+var stockMasterDir string
+
+// Dataset month in YYYYMM
+var datasetMonthPattern = regexp.MustCompile(`^\d{6}$`)
 
 func init() {
 	// Get the current working directory
@@ -23,28 +31,113 @@ func init() {
 		return
 	}
 
-	// Construct the file paths
-	stockfile = filepath.Join(wd, "stock_master_data", "stock_fundamental_202503.csv")
-	stockDetailfile = filepath.Join(wd, "stock_master_data", "stock_profile_202503.csv")
+	// Construct the directory path
+	stockMasterDir = filepath.Join(wd, "stock_master_data")
 }
 
-func importStockMasterDataFromCSV(c echo.Context) error {
+// Handler to import the dataset of a month, or a pair of uploaded CSV files.
+// A dataset is a full snapshot whose missing stocks are delisted; an uploaded stocks file
+// may be a part of the market, so its missing stocks are only delisted with delist=true.
+func createStockMasterImport(c echo.Context) error {
+	imp := &models.StockMasterImport{Batch: time.Now().Format("20060102T150405.000")}
+	delist := true
+
+	var stocks, stockDetails io.ReadCloser
+	if month := c.FormValue("month"); month != "" {
+		if !datasetMonthPattern.MatchString(month) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid month"})
+		}
+		imp.DatasetMonth = month
+		imp.StockFile = "stock_fundamental_" + month + ".csv"
+		imp.StockDetailFile = "stock_profile_" + month + ".csv"
+
+		var err error
+		if stocks, err = os.Open(filepath.Join(stockMasterDir, imp.StockFile)); err != nil {
+			return datasetErrorResponse(c, err)
+		}
+		defer stocks.Close()
+		if stockDetails, err = os.Open(filepath.Join(stockMasterDir, imp.StockDetailFile)); err != nil {
+			return datasetErrorResponse(c, err)
+		}
+		defer stockDetails.Close()
+	} else {
+		stockFile, err := c.FormFile("stocks")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Either month or the stocks and stock_details files are required"})
+		}
+		stockDetailFile, err := c.FormFile("stock_details")
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Either month or the stocks and stock_details files are required"})
+		}
+		imp.StockFile = stockFile.Filename
+		imp.StockDetailFile = stockDetailFile.Filename
+		delist = false
+		if v := c.FormValue("delist"); v != "" {
+			if delist, err = strconv.ParseBool(v); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid delist"})
+			}
+		}
 
-	err := repository.ImportStocks(stockfile)
+		if stocks, err = stockFile.Open(); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to read the stocks file"})
+		}
+		defer stocks.Close()
+		if stockDetails, err = stockDetailFile.Open(); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Failed to read the stock_details file"})
+		}
+		defer stockDetails.Close()
+	}
+
+	stockReport, stockDetailReport, err := repository.RunStockMasterImport(imp, stocks, stockDetails, delist)
 	if err != nil {
-		fmt.Println("Error importing stocks:", err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save data in Stock"})
+		fmt.Println("Error importing stock master data:", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to import stock master data"})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"import":        imp,
+		"stocks":        stockReport,
+		"stock_details": stockDetailReport,
+	})
+}
+
+// Write the response for a dataset file that cannot be opened
+func datasetErrorResponse(c echo.Context, err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "Dataset not found"})
 	}
+	fmt.Println("Error opening dataset:", err)
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to open dataset"})
+}
 
-	err = repository.ImportStockDetails(stockDetailfile)
+// Handler for the import history
+func getStockMasterImports(c echo.Context) error {
+	imports, err := repository.GetStockMasterImports()
 	if err != nil {
-		fmt.Println("Error importing stock details:", err)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to save data in StockDetail"})
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch imports"})
+	}
+	return c.JSON(http.StatusOK, imports)
+}
+
+// Handler for the changes logged by the imports
+func getStockMasterChanges(c echo.Context) error {
+	code := 0
+	if v := c.QueryParam("code"); v != "" {
+		var err error
+		if code, err = strconv.Atoi(v); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid stock code"})
+		}
 	}
 
-	return c.JSON(http.StatusCreated, nil)
+	changes, err := repository.GetStockMasterChanges(code, c.QueryParam("batch"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch changes"})
+	}
+	return c.JSON(http.StatusOK, changes)
 }
 
 func RegisterImportStockMasterDataFromCSV(e *echo.Group) {
-	e.GET("/stock_master", importStockMasterDataFromCSV)
+	e.POST("/stock_master/imports", createStockMasterImport)
+	e.GET("/stock_master/imports", getStockMasterImports)
+	e.GET("/stock_master/changes", getStockMasterChanges)
 }
@@ -0,0 +1,102 @@
+package routes_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"server/db"
+	"server/jpdate"
+	"server/marketdata"
+	"server/routes"
+)
+
+// Provider with news pages of 5 articles each, one article every 10 days back from now
+type newsProvider struct {
+	pages int
+	read  int // News pages fetched
+}
+
+func (p *newsProvider) Name() string { return "news" }
+
+func (p *newsProvider) Quote(ctx context.Context, code string) (marketdata.Quote, error) {
+	return marketdata.Quote{}, marketdata.ErrNotSupported
+}
+
+func (p *newsProvider) Profile(ctx context.Context, code string) (marketdata.Profile, error) {
+	return marketdata.Profile{}, marketdata.ErrNotSupported
+}
+
+func (p *newsProvider) News(ctx context.Context, code string, opts marketdata.NewsOptions) ([]marketdata.Article, error) {
+	now := time.Now().In(jpdate.JST)
+	return marketdata.CollectNews(ctx, marketdata.Pager{}, opts, func(ctx context.Context, page int) (marketdata.NewsPage, error) {
+		p.read++
+		var articles []marketdata.Article
+		for i := 0; i < 5; i++ {
+			n := (page-1)*5 + i
+			publishedAt := now.Add(-time.Duration(n)*10*24*time.Hour - 12*time.Hour)
+			articles = append(articles, marketdata.Article{
+				Title:  "article " + strconv.Itoa(n),
+				Link:   "https://example.com/news/" + strconv.Itoa(n),
+				Source: "適時開示",
+				Date:   publishedAt.Format("2006/01/02 15:04"),
+			})
+		}
+		return marketdata.NewsPage{Articles: articles, Last: page == p.pages}, nil
+	})
+}
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+	database, err := db.Open("file:" + t.Name() + "?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.DB = database
+}
+
+func TestStockNewsBackfillsWiderWindow(t *testing.T) {
+	openTestDB(t)
+	provider := &newsProvider{pages: 20}
+	e := echo.New()
+	routes.RegisterStockRoutes(e.Group("/api"), provider)
+
+	get := func(query string) int {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stocks/4385/news?source=all&"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", query, rec.Code, rec.Body)
+		}
+		total, _ := strconv.Atoi(rec.Header().Get("X-Total-Count"))
+		return total
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantTotal int
+		wantRead  int // News pages read in total
+	}{
+		{"first fetch", "days=30", 3, 1},
+		{"wider window pages past the saved articles", "days=100", 10, 4},
+		{"covered window within the refresh interval", "days=100", 10, 4},
+		{"narrower window within the refresh interval", "days=30", 10, 4},
+		{"page limit", "days=400&max_pages=1", 10, 5},
+		{"window reaching the last page", "days=2000", 100, 25},
+		{"every page read", "days=3000", 100, 25},
+	}
+	for _, tt := range tests {
+		if got := get(tt.query); got != tt.wantTotal {
+			t.Errorf("%s: total = %d, want %d", tt.name, got, tt.wantTotal)
+		}
+		if provider.read != tt.wantRead {
+			t.Errorf("%s: read %d pages, want %d", tt.name, provider.read, tt.wantRead)
+		}
+	}
+}
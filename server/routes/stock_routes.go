@@ -1,323 +1,409 @@
 package routes
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"log"
-	"math"
 	"net/http"
+	"server/alerts"
 	"server/db"
+	"server/handlers"
+	"server/jpdate"
+	"server/marketdata"
 	"server/models"
+	"server/repository"
 	"strconv"
-	"strings"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
-	"github.com/gocolly/colly/v2"
 	"github.com/labstack/echo/v4"
 )
 
-// Stock API
-type StockData struct {
-	Code         string  `json:"code"`
-	StockPrice   string  `json:"stock_price"`
-	MarketCap    string  `json:"market_cap"`
-	IssuedShares string  `json:"issued_shares"`
-	PrevClose    string  `json:"prev_close"`
-	PriceChange  string  `json:"price_change"`
-	StopHigh     bool    `json:"stop_high"`
-	AveragePER   float64 `json:"average_per"`
-	AveragePBR   float64 `json:"average_pbr"`
-}
-
-type Article struct {
-	Title  string `json:"title"`
-	Link   string `json:"link"`
-	Source string `json:"source"`
-	Date   string `json:"date"`
-}
+// Latest linked news articles returned with a stock
+const relatedNewsLimit = 10
 
-func stockDailyValue(code string) (StockData, error) {
-	c := colly.NewCollector(
-		colly.AllowedDomains("minkabu.jp"),
-	)
-
-	c.Limit(&colly.LimitRule{
-		DomainGlob:  "*minkabu.jp",
-		Delay:       1 * time.Second,
-		RandomDelay: 1 * time.Second,
-	})
-
-	// Initialize variables
-	var stockData StockData
-	stockData.Code = code
-	stopHigh := false
-
-	// Extract stock information from Minkabu
-	c.OnHTML(".stock_price", func(e *colly.HTMLElement) {
-		stockData.StockPrice = strings.TrimSpace(e.Text)
-		stockData.StockPrice = strings.ReplaceAll(stockData.StockPrice, "\n", "")
-		stockData.StockPrice = strings.TrimSpace(strings.Split(stockData.StockPrice, "円")[0])
-		stockData.StockPrice = strings.ReplaceAll(stockData.StockPrice, ",", "")
-	})
-
-	c.OnHTML("table.md_table tbody tr", func(e *colly.HTMLElement) {
-		label := strings.TrimSpace(e.ChildText("th"))
-		value := strings.TrimSpace(e.ChildText("td"))
-
-		// Identify the data based on the label
-		switch label {
-		case "時価総額": // Market capitalization
-			value = strings.ReplaceAll(value, "百万円", ",000,000")
-			// value = strings.ReplaceAll(value, ",", "")
-			stockData.MarketCap = value
-		case "発行済株数": // Issued shares
-			value = strings.ReplaceAll(value, "千株", ",000")
-			// value = strings.ReplaceAll(value, ",", "")
-			stockData.IssuedShares = value
+// Handler for stock daily value
+func getStockInfo(provider marketdata.MarketDataProvider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// For Debugging
+		// fmt.Println("Request Body:", c.Request().Body)
+		code := c.Param("code")
+		if code == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Stock code is required"})
 		}
-	})
 
-	c.OnHTML("table.md_table.theme_light tr.ly_vamd", func(e *colly.HTMLElement) {
-		label := strings.TrimSpace(e.ChildText("th"))
-		value := strings.TrimSpace(e.ChildText("td"))
-
-		if strings.Contains(label, "前日終値") { // Match "前日終値"
-			value = strings.ReplaceAll(value, "円", "")
-			value = strings.ReplaceAll(value, ",", "")
-			stockData.PrevClose = value
+		var stock models.Stock
+		var stockDetail models.StockDetail
+		if err := db.DB.Where("stock_code = ?", code).First(&stock).Error; err != nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "Stock not found"})
 		}
-	})
 
-	// Extract price change and check if "STOP高" exists
-	c.OnHTML(".md_stockBoard_stockTable", func(e *colly.HTMLElement) {
-		stockData.PriceChange = strings.TrimSpace(e.ChildText(".stock_price_diff"))
-		if e.ChildText(".hi") == "STOP高" {
-			stopHigh = true
-		}
-	})
-
-	// Variables for financial data
-	var perSum, pbrSum float64
-	var count float64
-
-	// Extract PER and PBR values
-	c.OnHTML("table.md_table tr", func(e *colly.HTMLElement) {
-		cells := e.DOM.Find("td").Map(func(i int, s *goquery.Selection) string {
-			return strings.TrimSpace(s.Text())
-		})
-
-		if len(cells) >= 4 {
-			per, err1 := strconv.ParseFloat(strings.ReplaceAll(cells[2], ",", ""), 64)
-			pbr, err2 := strconv.ParseFloat(strings.ReplaceAll(cells[3], ",", ""), 64)
-			if err1 == nil && err2 == nil {
-				perSum += per
-				pbrSum += pbr
-				count++
-			}
+		if err := db.DB.Where("stock_code = ?", code).First(&stockDetail).Error; err != nil {
+			log.Println("The stock might be vernished from market?, CODE: ", code, err)
 		}
-	})
-
-	c.OnScraped(func(r *colly.Response) {
-		if count > 0 {
-			stockData.AveragePER = math.Round((perSum/count)*100) / 100
-			stockData.AveragePBR = math.Round((pbrSum/count)*100) / 100
-		} else {
-			stockData.AveragePER = 0
-			stockData.AveragePBR = 0
+
+		ctx, cancel := context.WithTimeout(c.Request().Context(), quoteTimeout)
+		defer cancel()
+
+		stockData, err := provider.Quote(ctx, code)
+		if err != nil {
+			log.Println("Failed to fetch stock data, CODE: ", code, err)
+			return scrapeErrorResponse(c, err, "Failed to fetch stock data")
 		}
-		stockData.StopHigh = stopHigh
-	})
 
-	c.OnError(func(r *colly.Response, err error) {
-		log.Printf("Failed to crawl %s: %v", r.Request.URL, err)
-	})
+		storeQuote(stock.StockCode, stockData)
+
+		// Market news that mention the stock
+		relatedNews, err := repository.GetStockNewsArticles(stock.StockCode, relatedNewsLimit)
+		if err != nil {
+			log.Println("Failed to fetch related news, CODE: ", code, err)
+			relatedNews = []models.NewsArticle{}
+		}
 
-	// Visit the stock page
-	yahoo_url := fmt.Sprintf("https://minkabu.jp/stock/%s", code)
-	c.Visit(yahoo_url)
-	minkabu_url := fmt.Sprintf("https://minkabu.jp/stock/%s/daily_valuation", code)
-	c.Visit(minkabu_url)
+		response := map[string]interface{}{
+			"stock":       stock,
+			"stockDetail": stockDetail,
+			"stockData":   stockData,
+			"relatedNews": relatedNews,
+		}
 
-	// For async processing
-	time.Sleep(5 * time.Second)
+		// DEBUG
+		// log.Println(response)
 
-	return stockData, nil
+		return c.JSON(http.StatusOK, response)
+	}
 }
 
-// Handler for stock daily value
-func getStockInfo(c echo.Context) error {
-	// For Debugging
-	// fmt.Println("Request Body:", c.Request().Body)
-	code := c.Param("code")
-	if code == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Stock code is required"})
+// Keep the snapshot as price history and check the alert rules against it
+func storeQuote(stockCode int, stockData marketdata.Quote) {
+	quote := newStockQuote(stockCode, stockData)
+	if err := repository.SaveStockQuote(quote); err != nil {
+		log.Println("Failed to save stock quote, CODE: ", stockCode, err)
+		return
 	}
-
-	var stock models.Stock
-	var stockDetail models.StockDetail
-	if err := db.DB.Where("stock_code = ?", code).First(&stock).Error; err != nil {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "Stock not found"})
+	if _, err := alerts.Evaluate(quote); err != nil {
+		log.Println("Failed to evaluate alert rules, CODE: ", stockCode, err)
 	}
+}
 
-	if err := db.DB.Where("stock_code = ?", code).First(&stockDetail).Error; err != nil {
-		log.Println("The stock might be vernished from market?, CODE: ", code, err)
+// Convert the scraped values into a daily snapshot
+func newStockQuote(stockCode int, stockData marketdata.Quote) *models.StockQuote {
+	return &models.StockQuote{
+		StockCode:          stockCode,
+		TradingDate:        repository.TradingDate(time.Now()),
+		StockPrice:         stockData.StockPrice,
+		PrevClose:          stockData.PrevClose,
+		PriceChange:        stockData.PriceChange,
+		PriceChangePercent: stockData.PriceChangePercent,
+		MarketCap:          stockData.MarketCap,
+		IssuedShares:       stockData.IssuedShares,
+		AveragePER:         stockData.AveragePER,
+		AveragePBR:         stockData.AveragePBR,
+		StopHigh:           stockData.StopHigh,
 	}
+}
 
-	stockData, err := stockDailyValue(code)
+// Handler for stock price history
+func getStockHistory(c echo.Context) error {
+	code, err := strconv.Atoi(c.Param("code"))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch stock data"})
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid stock code"})
 	}
 
-	response := map[string]interface{}{
-		"stock":       stock,
-		"stockDetail": stockDetail,
-		"stockData":   stockData,
+	// Optional range in YYYY-MM-DD
+	var from, to time.Time
+	if v := c.QueryParam("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid from date"})
+		}
+	}
+	if v := c.QueryParam("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid to date"})
+		}
 	}
 
-	// DEBUG
-	// log.Println(response)
+	quotes, err := repository.GetStockQuoteHistory(code, from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch stock history"})
+	}
 
-	return c.JSON(http.StatusOK, response)
+	return c.JSON(http.StatusOK, quotes)
 }
 
-// Judge if the date is within one year
-func isWithinOneYear(dateStr string) (bool, time.Time) {
-	layoutFull := "2006/01/02" // Format for full date (YYYY/MM/DD)
-	// layoutShort := "01/02"     // Format for month/day (MM/DD)
-
-	// Remove time part (HH:mm) if present
-	dateParts := strings.Split(dateStr, " ")
-	cleanDate := dateParts[0] // Extract only the date part
-
-	// Get current date
-	now := time.Now()
-	oneYearAgo := now.AddDate(-1, 0, 0)
-
-	var parsedDate time.Time
-	var err error
-
-	// Try parsing with full date format (YYYY/MM/DD)
-	parsedDate, err = time.Parse(layoutFull, cleanDate)
-	if err == nil {
-		return parsedDate.After(oneYearAgo), parsedDate
-	}
+// Page size of the stock news
+const (
+	defaultStockNewsLimit = 100
+	maxStockNewsLimit     = 500
+)
 
-	// If parsing fails, try short format (MM/DD) and assume it's from this year or last year
-	thisYear := now.Year()
-	lastYear := now.Year() - 1
+// Handler for stock news, served from the saved articles after fetching the new ones.
+// The total count of the filter is in the X-Total-Count header.
+func getStockNews(provider marketdata.MarketDataProvider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		code, err := strconv.Atoi(c.Param("code"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid stock code"})
+		}
+		filter, opts, err := stockNewsQuery(c, code)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 
-	parsedDate, err = time.Parse(layoutFull, fmt.Sprintf("%d/%s", thisYear, cleanDate))
-	if err == nil && parsedDate.After(oneYearAgo) {
-		return true, parsedDate
-	}
+		// A failed fetch is only an error while nothing is saved yet
+		saved, err := refreshStockNews(c.Request().Context(), provider, code, opts)
+		if err != nil {
+			log.Println("Failed to fetch news, CODE: ", code, err)
+			if !saved {
+				return scrapeErrorResponse(c, err, "Failed to fetch news")
+			}
+		}
 
-	parsedDate, err = time.Parse(layoutFull, fmt.Sprintf("%d/%s", lastYear, cleanDate))
-	if err == nil && parsedDate.After(oneYearAgo) {
-		return true, parsedDate
+		disclosures, total, err := repository.GetStockDisclosures(filter)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to retrieve news"})
+		}
+		c.Response().Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+		return c.JSON(http.StatusOK, disclosures)
 	}
-
-	log.Printf("Date parsing failed for: %s", dateStr)
-	return false, time.Time{}
 }
 
-func stockNews(code string) ([]Article, error) {
-	var articles []Article
+// Last event of a stream of stock news, with the page that getStockNews would answer
+type stockNewsSummary struct {
+	Pages    int                      `json:"pages"`   // News pages read
+	Fetched  int                      `json:"fetched"` // Articles sent as they were read
+	Total    int64                    `json:"total"`
+	Articles []models.StockDisclosure `json:"articles"`
+}
 
-	baseURL := "https://minkabu.jp/stock/" + code + "/news?page="
-	page := 1
-	stopCrawling := false
+// Handler for stock news as server-sent events, with the same query as getStockNews.
+// A "page" event tells each news page read, followed by an "article" event for each of its new articles of the sources,
+// and a "summary" event ends the stream; a failed fetch ends it with an "error" event instead.
+func streamStockNews(provider marketdata.MarketDataProvider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		code, err := strconv.Atoi(c.Param("code"))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid stock code"})
+		}
+		filter, opts, err := stockNewsQuery(c, code)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
 
-	for {
-		if stopCrawling {
-			break
+		stream := newEventStream(c)
+		summary := stockNewsSummary{}
+		sources := map[string]bool{}
+		for _, source := range filter.Sources {
+			sources[source] = true
+		}
+		now := time.Now()
+		opts.OnPage = func(page int, articles []marketdata.Article) {
+			summary.Pages = page
+			stream.send("page", map[string]int{"page": page, "articles": len(articles)})
+			for _, article := range articles {
+				if len(sources) > 0 && !sources[article.Source] {
+					continue
+				}
+				summary.Fetched++
+				stream.send("article", newStockDisclosure(code, article, now))
+			}
 		}
 
-		url := fmt.Sprintf("%s%d", baseURL, page)
-		fmt.Println("Visiting:", url)
+		saved, err := refreshStockNews(c.Request().Context(), provider, code, opts)
+		if err != nil {
+			log.Println("Failed to fetch news, CODE: ", code, err)
+			if !saved {
+				return stream.fail(err, "Failed to fetch news")
+			}
+		}
 
-		c := colly.NewCollector(
-			colly.AllowedDomains("minkabu.jp"),
-		)
+		disclosures, total, err := repository.GetStockDisclosures(filter)
+		if err != nil {
+			stream.send("error", map[string]interface{}{"status": http.StatusInternalServerError, "error": "Failed to retrieve news"})
+			return nil
+		}
+		summary.Total = total
+		summary.Articles = disclosures
+		stream.send("summary", summary)
+		return nil
+	}
+}
 
-		c.Limit(&colly.LimitRule{
-			DomainGlob:  "*minkabu.jp",
-			Delay:       1 * time.Second,
-			RandomDelay: 1 * time.Second,
-		})
+// Filter of the saved stock news and bounds of the fetch, from the query
+func stockNewsQuery(c echo.Context, code int) (repository.StockDisclosureFilter, marketdata.NewsOptions, error) {
+	// Sources to serve, e.g. source=適時開示,PR TIMES or source=all
+	filter := repository.StockDisclosureFilter{StockCode: code, Sources: marketdata.DefaultNewsSources, Limit: defaultStockNewsLimit}
+	if v := c.QueryParam("source"); v == "all" {
+		filter.Sources = nil
+	} else if v != "" {
+		filter.Sources = handlers.SplitQueryParam(c, "source")
+	}
+	if v := c.QueryParam("since"); v != "" {
+		since, err := jpdate.Parse(v, time.Now())
+		if err != nil {
+			return filter, marketdata.NewsOptions{}, errors.New("Invalid since date")
+		}
+		filter.Since = &since
+	}
 
-		pageHasArticles := false
+	// Bounds of the fetch: the lookback window in days and the number of news pages
+	opts := marketdata.NewsOptions{Sources: []string{}}
+	if v := c.QueryParam("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return filter, opts, errors.New("Invalid days")
+		}
+		opts.Window = time.Duration(n) * 24 * time.Hour
+	}
+	if v := c.QueryParam("max_pages"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return filter, opts, errors.New("Invalid max_pages")
+		}
+		opts.MaxPages = n
+	}
+	if v := c.QueryParam("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return filter, opts, errors.New("Invalid limit")
+		}
+		filter.Limit = min(n, maxStockNewsLimit)
+	}
+	if v := c.QueryParam("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return filter, opts, errors.New("Invalid offset")
+		}
+		filter.Offset = n
+	}
+	return filter, opts, nil
+}
 
-		c.OnHTML(".md_card_ti", func(e *colly.HTMLElement) {
-			if strings.Contains(e.Text, "ページが見つかりませんでした") {
-				fmt.Println("Page not found. Stopping crawl.")
-				stopCrawling = true
-			}
-		})
-
-		c.OnHTML("li", func(e *colly.HTMLElement) {
-			title := strings.TrimSpace(e.ChildText(".title_box a"))
-			link := e.ChildAttr(".title_box a", "href")
-			source := strings.TrimSpace(e.ChildText(".fcgl"))
-			date := strings.TrimSpace(e.ChildText(".flex.items-center"))
-
-			if source == "適時開示" || source == "PR TIMES" {
-				isRecent, articleDate := isWithinOneYear(date)
-				if !isRecent {
-					fmt.Printf("Found old article (%s), stopping crawl.\n", articleDate.Format("2006/01/02"))
-					stopCrawling = true
-					return
-				}
+// Fetch the articles of every source published since the latest saved one, at most once per interval for each stock.
+// When the saved articles do not reach back to the window of opts, the pages are read past them to the window again.
+// Whether any article of the stock is saved is returned with the error.
+func refreshStockNews(ctx context.Context, provider marketdata.MarketDataProvider, code int, opts marketdata.NewsOptions) (bool, error) {
+	known, err := repository.GetStockDisclosureLinks(code)
+	if err != nil {
+		return false, err
+	}
+	coverage, err := repository.GetStockNewsCoverage(code)
+	if err != nil {
+		return len(known) > 0, err
+	}
 
-				// Debug
-				// fmt.Println("DEBUG: Extracted Title:", title)
-				// fmt.Println("DEBUG: Extracted Link:", link)
-				// fmt.Println("DEBUG: Extracted Source:", source)
-				// fmt.Println("DEBUG: Extracted Date:", date)
+	now := time.Now()
+	window := opts.Window
+	if window <= 0 {
+		window = marketdata.DefaultNewsWindow
+	}
+	cutoff := now.Add(-window)
+	covered := coverage != nil && (coverage.AllPages || !coverage.Since.After(cutoff))
+	if covered && now.Sub(coverage.FetchedAt) < stockNewsRefreshInterval {
+		return len(known) > 0, nil
+	}
 
-				articles = append(articles, Article{
-					Title:  title,
-					Link:   "https://minkabu.jp" + link,
-					Source: source,
-					Date:   date,
-				})
+	ctx, cancel := context.WithTimeout(ctx, newsTimeout)
+	defer cancel()
 
-				pageHasArticles = true
+	if covered {
+		opts.Known = func(link string) bool { return known[link] }
+	}
+	// Only the articles not saved yet are new to the caller
+	if onPage := opts.OnPage; onPage != nil {
+		opts.OnPage = func(page int, articles []marketdata.Article) {
+			fresh := []marketdata.Article{}
+			for _, article := range articles {
+				if !known[article.Link] {
+					fresh = append(fresh, article)
+				}
 			}
-		})
-
-		if !pageHasArticles {
-			fmt.Println("No articles found on this page: ", page)
-		}
-
-		err := c.Visit(url)
-		if err != nil {
-			log.Println("Failed to visit:", err)
-			break
+			onPage(page, fresh)
 		}
+	}
+	var read marketdata.NewsCoverage
+	opts.Coverage = &read
+	articles, err := provider.News(ctx, strconv.Itoa(code), opts)
+	if err != nil {
+		// The articles are only saved after a complete fetch, so that the next one does not stop before the ones left out
+		return len(known) > 0, err
+	}
 
-		page++
+	disclosures := make([]models.StockDisclosure, len(articles))
+	for i, article := range articles {
+		disclosures[i] = newStockDisclosure(code, article, now)
+	}
+	if err := repository.SaveStockDisclosures(disclosures); err != nil {
+		return len(known) > 0, err
 	}
 
-	return articles, nil
+	if err := repository.SaveStockNewsCoverage(mergeNewsCoverage(code, coverage, read, cutoff, now)); err != nil {
+		return true, err
+	}
+	return true, nil
 }
 
-// Handler for stock news
-func getStockNews(c echo.Context) error {
-	code := c.Param("code")
-	if code == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Stock code is required"})
+// The coverage of the saved articles of a stock after a fetch at now that read the pages as far as read
+func mergeNewsCoverage(code int, saved *models.StockNewsCoverage, read marketdata.NewsCoverage, cutoff, now time.Time) *models.StockNewsCoverage {
+	merged := &models.StockNewsCoverage{StockCode: code, Since: now, FetchedAt: now}
+	switch {
+	case read.LastPage:
+		merged.AllPages = true
+	case read.Window:
+		merged.Since = cutoff
+	case !read.Oldest.IsZero():
+		merged.Since = read.Oldest
 	}
 
-	articles, err := stockNews(code)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "Failed to fetch news"})
+	// The saved range still counts where the fetch reached it
+	if saved != nil && (read.Known || !merged.Since.After(saved.FetchedAt)) {
+		merged.AllPages = merged.AllPages || saved.AllPages
+		if saved.Since.Before(merged.Since) {
+			merged.Since = saved.Since
+		}
+	}
+	return merged
+}
+
+// Convert a scraped article of the stock into its saved form
+func newStockDisclosure(code int, article marketdata.Article, now time.Time) models.StockDisclosure {
+	disclosure := models.StockDisclosure{
+		StockCode: code,
+		Title:     article.Title,
+		Link:      article.Link,
+		Source:    article.Source,
+		Date:      article.Date,
+	}
+	if publishedAt, err := jpdate.Parse(article.Date, now); err == nil {
+		disclosure.PublishedAt = &publishedAt
 	}
+	return disclosure
+}
 
-	return c.JSON(http.StatusOK, articles)
+// Handler for company profile
+func getStockProfile(provider marketdata.MarketDataProvider) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		code := c.Param("code")
+
+		ctx, cancel := context.WithTimeout(c.Request().Context(), quoteTimeout)
+		defer cancel()
+
+		profile, err := provider.Profile(ctx, code)
+		if err != nil {
+			log.Println("Failed to fetch profile, CODE: ", code, err)
+			return scrapeErrorResponse(c, err, "Failed to fetch profile")
+		}
+
+		return c.JSON(http.StatusOK, profile)
+	}
 }
 
 // RegisterStockRoutes registers stock routes
-func RegisterStockRoutes(e *echo.Group) {
-	e.GET("/stocks/:code", getStockInfo)
-	e.GET("/stocks/:code/news", getStockNews)
+func RegisterStockRoutes(e *echo.Group, provider marketdata.MarketDataProvider) {
+	e.GET("/stocks/screen", handlers.ScreenStocks)
+	e.GET("/stocks/search", handlers.SearchStocks)
+	e.GET("/stocks/:code", getStockInfo(provider))
+	e.GET("/stocks/:code/news", getStockNews(provider))
+	e.GET("/stocks/:code/news/stream", streamStockNews(provider))
+	e.GET("/stocks/:code/profile", getStockProfile(provider))
+	e.GET("/stocks/:code/history", getStockHistory)
 }
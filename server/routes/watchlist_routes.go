@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"server/handlers"
+
+	"github.com/labstack/echo/v4"
+)
+
+func RegisterWatchlistRoutes(e *echo.Group) {
+	e.GET("/watchlist", handlers.GetWatchlist)
+	e.POST("/watchlist", handlers.AddWatchlistItem)
+	e.PUT("/watchlist/:code", handlers.UpdateWatchlistItem)
+	e.DELETE("/watchlist/:code", handlers.DeleteWatchlistItem)
+}
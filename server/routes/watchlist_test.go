@@ -0,0 +1,59 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"server/db"
+	"server/models"
+	"server/routes"
+)
+
+func TestWatchlist(t *testing.T) {
+	openTestDB(t)
+	if err := db.DB.Create(&models.Stock{StockCode: 4385, StockName: "メルカリ"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	e := echo.New()
+	routes.RegisterWatchlistRoutes(e.Group("/api"))
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	steps := []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"add", http.MethodPost, "/api/watchlist", `{"stock_code": 4385}`, http.StatusCreated},
+		{"add again", http.MethodPost, "/api/watchlist", `{"stock_code": 4385}`, http.StatusConflict},
+		{"add an unknown stock", http.MethodPost, "/api/watchlist", `{"stock_code": 9999}`, http.StatusNotFound},
+		{"update", http.MethodPut, "/api/watchlist/4385", `{"note": "決算待ち", "sort_order": 2}`, http.StatusOK},
+		{"update an unwatched stock", http.MethodPut, "/api/watchlist/9999", `{"note": "x"}`, http.StatusNotFound},
+		{"update with an invalid code", http.MethodPut, "/api/watchlist/abc", `{}`, http.StatusBadRequest},
+	}
+	for _, step := range steps {
+		if rec := request(step.method, step.path, step.body); rec.Code != step.want {
+			t.Errorf("%s: status %d, want %d: %s", step.name, rec.Code, step.want, rec.Body)
+		}
+	}
+
+	// The update keeps the stock and changes only the note and the sort order
+	rec := request(http.MethodGet, "/api/watchlist", "")
+	var items []models.WatchlistItem
+	if err := json.Unmarshal(rec.Body.Bytes(), &items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].StockCode != 4385 || items[0].Note != "決算待ち" || items[0].SortOrder != 2 {
+		t.Errorf("watchlist = %+v, want 4385 with the note and sort order", items)
+	}
+}
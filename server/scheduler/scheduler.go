@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Tokyo Stock Exchange time zone
+var jst = time.FixedZone("JST", 9*60*60)
+
+// Trading sessions of the TSE in minutes from midnight (JST)
+var sessions = [][2]int{
+	{9 * 60, 11*60 + 30},     // Morning session
+	{12*60 + 30, 15*60 + 30}, // Afternoon session
+}
+
+// IsTradingHours reports whether the TSE is open at t.
+// Exchange holidays are not taken into account.
+func IsTradingHours(t time.Time) bool {
+	t = t.In(jst)
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	for _, s := range sessions {
+		if minute >= s[0] && minute <= s[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Run calls job every interval during trading hours until ctx is cancelled
+func Run(ctx context.Context, interval time.Duration, job func(context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Scheduler started (interval: %s)", interval)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Scheduler stopped")
+			return
+		case now := <-ticker.C:
+			if !IsTradingHours(now) {
+				continue
+			}
+			job(ctx)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package stockmaster defines the columns of the stock master records, shared by the crawler that
+// writes them and the server that imports them, and parses the records into the models.
+package stockmaster
+
+import (
+	"errors"
+	"fmt"
+	"server/models"
+	"server/normalize"
+	"strconv"
+	"strings"
+)
+
+// Columns of the stocks, as crawled from minkabu
+const (
+	StockCode          = "銘柄コード"
+	ListingSection     = "上場区分"
+	StockName          = "銘柄"
+	StockPrice         = "株価"
+	CompanyName        = "社名"
+	EnglishCompanyName = "英文社名"
+	Industry           = "業種"
+	Representative     = "代表者"
+	SettlementMonth    = "決算"
+	Capital            = "資本金"
+	Address            = "住所"
+	Phone              = "電話番号(IR)"
+	ListingMarket      = "上場市場"
+	ListingDate        = "上場年月日"
+	UnitShares         = "単元株数"
+)
+
+// Columns of the stock details, as crawled from Yahoo Finance
+const (
+	Feature               = "特色"
+	Business              = "連結事業"
+	EmployeesSolo         = "従業員数（単独）"
+	EmployeesConsolidated = "従業員数（連結）"
+	AverageAge            = "平均年齢"
+	AverageSalary         = "平均年収"
+)
+
+// StockColumns are the columns of a stocks file, in order
+var StockColumns = []string{
+	StockCode, ListingSection, StockName, StockPrice, CompanyName, EnglishCompanyName, Industry, Representative,
+	SettlementMonth, Capital, Address, Phone, ListingMarket, ListingDate, UnitShares,
+}
+
+// StockDetailColumns are the columns of a stock details file, in order
+var StockDetailColumns = []string{
+	StockCode, Feature, Business, EmployeesSolo, EmployeesConsolidated, AverageAge, AverageSalary,
+}
+
+// Record is a crawled stock or stock details, the values as displayed by the site keyed by the column
+type Record map[string]string
+
+// RecordOf keys a CSV row by the header
+func RecordOf(header, row []string) (Record, error) {
+	if len(row) != len(header) {
+		return nil, fmt.Errorf("expected %d columns, got %d", len(header), len(row))
+	}
+	record := make(Record, len(header))
+	for i, column := range header {
+		record[columnName(column)] = row[i]
+	}
+	return record, nil
+}
+
+// Column of a header, without the byte order mark that Excel puts at the head of the file
+func columnName(header string) string {
+	return strings.TrimPrefix(header, "\ufeff")
+}
+
+// Row returns the values of the columns in order, for a CSV file
+func (r Record) Row(columns []string) []string {
+	row := make([]string, len(columns))
+	for i, column := range columns {
+		row[i] = r[column]
+	}
+	return row
+}
+
+// CheckHeader reports the columns missing from the header of a CSV file
+func CheckHeader(header, columns []string) error {
+	present := make(map[string]bool, len(header))
+	for _, column := range header {
+		present[columnName(column)] = true
+	}
+
+	var missing []string
+	for _, column := range columns {
+		if !present[column] {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing columns %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// IsColumn reports whether the label is one of the columns
+func IsColumn(columns []string, label string) bool {
+	for _, column := range columns {
+		if column == label {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseStockCode parses a stock code, e.g. "4385" -> 4385
+func ParseStockCode(s string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || code <= 0 {
+		return 0, fmt.Errorf("invalid stock code %q", s)
+	}
+	return code, nil
+}
+
+// Treat a placeholder such as "---" as the zero value
+func optional[T any](value T, err error) (T, error) {
+	if errors.Is(err, normalize.ErrNoValue) {
+		return value, nil
+	}
+	return value, err
+}
+
+// ParseStock converts a stocks record into the model
+func ParseStock(r Record) (models.Stock, error) {
+	stockCode, err := ParseStockCode(r[StockCode])
+	if err != nil {
+		return models.Stock{}, err
+	}
+	settlementMonth, err := optional(normalize.Month(r[SettlementMonth]))
+	if err != nil {
+		return models.Stock{}, fmt.Errorf("settlement month: %w", err)
+	}
+	capital, err := optional(normalize.Yen(r[Capital]))
+	if err != nil {
+		return models.Stock{}, fmt.Errorf("capital: %w", err)
+	}
+	unitShares, err := optional(normalize.Shares(r[UnitShares]))
+	if err != nil {
+		return models.Stock{}, fmt.Errorf("unit shares: %w", err)
+	}
+	listingDate := strings.NewReplacer("年", "/", "月", "/", "日", "").Replace(r[ListingDate])
+
+	return models.Stock{
+		StockCode:          stockCode,
+		MarketType:         r[ListingSection],
+		StockName:          r[StockName],
+		StockExist:         r[StockPrice] != "---", // No price for a stock no longer traded
+		CompanyName:        r[CompanyName],
+		EnglishCompanyName: r[EnglishCompanyName],
+		Industry:           r[Industry],
+		Representative:     r[Representative],
+		SettlementMonth:    settlementMonth,
+		Capital:            int(capital),
+		Address:            r[Address],
+		Phone:              r[Phone],
+		ListingMarket:      r[ListingMarket],
+		ListingDate:        listingDate,
+		UnitShares:         int(unitShares),
+	}, nil
+}
+
+// ParseStockDetail converts a stock details record into the model
+func ParseStockDetail(r Record) (models.StockDetail, error) {
+	stockCode, err := ParseStockCode(r[StockCode])
+	if err != nil {
+		return models.StockDetail{}, err
+	}
+	employeesSolo, err := optional(normalize.Count(r[EmployeesSolo]))
+	if err != nil {
+		return models.StockDetail{}, fmt.Errorf("employees (solo): %w", err)
+	}
+	employeesConsolidated, err := optional(normalize.Count(r[EmployeesConsolidated]))
+	if err != nil {
+		return models.StockDetail{}, fmt.Errorf("employees (consolidated): %w", err)
+	}
+	averageAge, err := optional(normalize.Age(r[AverageAge]))
+	if err != nil {
+		return models.StockDetail{}, fmt.Errorf("average age: %w", err)
+	}
+	averageSalary, err := optional(normalize.Yen(r[AverageSalary]))
+	if err != nil {
+		return models.StockDetail{}, fmt.Errorf("average salary: %w", err)
+	}
+
+	return models.StockDetail{
+		StockCode:             stockCode,
+		Feature:               r[Feature],
+		Business:              r[Business],
+		EmployeesSolo:         int(employeesSolo),
+		EmployeesConsolidated: int(employeesConsolidated),
+		AverageAge:            averageAge,
+		AverageSalary:         int(averageSalary),
+	}, nil
+}
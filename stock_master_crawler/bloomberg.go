@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"server/news"
+)
+
+// Print the articles linked from the homepage, and the descriptions read from their pages if asked
+func bloomTopNews(w io.Writer, descriptions bool) error {
+	bloomberg := news.NewBloomberg(bloombergBaseURL)
+	ctx := context.Background()
+
+	articles, err := bloomberg.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if !descriptions {
+		for _, article := range articles {
+			fmt.Fprintf(w, "Link found: %s\nTitle: %s\n", article.Link, article.Title)
+		}
+		return nil
+	}
+
+	links := make([]string, len(articles))
+	for i, article := range articles {
+		links[i] = article.Link
+	}
+	details, err := bloomberg.Articles(ctx, links)
+	for _, article := range articles {
+		// Pages that failed or have no JSON-LD have no description
+		detail := details[article.Link]
+		if detail.Description == "" {
+			continue
+		}
+		fmt.Fprintf(w, "Article found: %s\n", article.Title)
+		fmt.Fprintln(w, "Description:", detail.Description)
+		fmt.Fprintln(w, "--------------------------------------------------")
+	}
+	return err
+}
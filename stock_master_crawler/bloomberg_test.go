@@ -0,0 +1,28 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"server/fixtureserver"
+)
+
+func TestBloomTopNews(t *testing.T) {
+	for _, tt := range []struct {
+		golden       string
+		descriptions bool
+	}{
+		{"bloomberg_top.json", false},
+		{"bloomberg_descriptions.json", true}, // Only the first article page is saved
+	} {
+		t.Run(tt.golden, func(t *testing.T) {
+			var out strings.Builder
+			if err := bloomTopNews(&out, tt.descriptions); err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			fixtureserver.Golden(t, filepath.Join("testdata", "golden", tt.golden), lines, hosts, *update)
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+// Outcomes of a finished code
+const (
+	outcomeSaved    = "saved"     // Written to the output
+	outcomeNotFound = "not_found" // The site has no page for the code
+	outcomeNoData   = "no_data"   // The page has nothing to write
+)
+
+// Options of a resumable crawl
+type resumeOptions struct {
+	state   string
+	restart bool
+	retries int
+	backoff time.Duration
+}
+
+func (o *resumeOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.state, "state", "", "checkpoint file of the finished and failed codes (default the sink file with .state.json)")
+	fs.BoolVar(&o.restart, "restart", false, "ignore the checkpoint, crawling again every code missing from the output")
+	fs.IntVar(&o.retries, "retries", 3, "number of retries of the failed codes")
+	fs.DurationVar(&o.backoff, "backoff", 30*time.Second, "wait before the first retry, doubled for each retry")
+}
+
+func (o *resumeOptions) validate() error {
+	if o.retries < 0 {
+		return errors.New("-retries must not be negative")
+	}
+	if o.backoff < 0 {
+		return errors.New("-backoff must not be negative")
+	}
+	return nil
+}
+
+// Failed attempts of a code
+type codeFailure struct {
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+	At       time.Time `json:"at"`
+}
+
+// How often the checkpoint is saved during a crawl: after this many changed codes or this long after the last save
+const (
+	saveEvery    = 100
+	saveInterval = 10 * time.Second
+)
+
+// Checkpoint of a crawl, saved to a JSON file every few codes and at the end of each pass,
+// so that an interrupted crawl resumes with the codes left.
+// Codes saved to the output but missed by the checkpoint are taken from the output on resume.
+type checkpoint struct {
+	mu       sync.Mutex
+	filename string
+	changed  int       // Codes changed since the last save
+	savedAt  time.Time // Time of the last save
+
+	Done   map[string]string       `json:"done"` // Code -> outcome
+	Failed map[string]*codeFailure `json:"failed"`
+}
+
+// Load the checkpoint of the crawl writing to output, or start a new one
+func (o resumeOptions) load(output string) (*checkpoint, error) {
+	filename := o.state
+	if filename == "" {
+		filename = output + ".state.json"
+	}
+	state := &checkpoint{filename: filename, savedAt: time.Now(), Done: map[string]string{}, Failed: map[string]*codeFailure{}}
+	if o.restart {
+		return state, nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", filename, err)
+	}
+	if state.Done == nil {
+		state.Done = map[string]string{}
+	}
+	if state.Failed == nil {
+		state.Failed = map[string]*codeFailure{}
+	}
+	log.Printf("Resuming from %s: %d codes done, %d failed", filename, len(state.Done), len(state.Failed))
+	return state, nil
+}
+
+// Codes not finished yet, in the given order
+func (s *checkpoint) pending(codes []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pending []string
+	for _, code := range codes {
+		if _, done := s.Done[code]; !done {
+			pending = append(pending, code)
+		}
+	}
+	return pending
+}
+
+func (s *checkpoint) done(code, outcome string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Done[code] = outcome
+	delete(s.Failed, code)
+	return s.changedLocked()
+}
+
+func (s *checkpoint) fail(code string, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A code that has finished in the meantime stays finished
+	if _, done := s.Done[code]; done {
+		return nil
+	}
+	failure := s.Failed[code]
+	if failure == nil {
+		failure = &codeFailure{}
+		s.Failed[code] = failure
+	}
+	failure.Attempts++
+	failure.Error = err.Error()
+	failure.At = time.Now()
+	return s.changedLocked()
+}
+
+// Whether a failure of the code has been recorded since t
+func (s *checkpoint) failedSince(code string, t time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failure := s.Failed[code]
+	return failure != nil && !failure.At.Before(t)
+}
+
+// Count a changed code, saving the file once enough codes or time have passed
+func (s *checkpoint) changedLocked() error {
+	s.changed++
+	if s.changed < saveEvery && time.Since(s.savedAt) < saveInterval {
+		return nil
+	}
+	return s.saveLocked()
+}
+
+// Save the changes not saved yet
+func (s *checkpoint) save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.changed == 0 {
+		return nil
+	}
+	return s.saveLocked()
+}
+
+// Write the file through a temporary file, so that a crash never leaves half of it
+func (s *checkpoint) saveLocked() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.filename + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, s.filename); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	s.changed = 0
+	s.savedAt = time.Now()
+	return nil
+}
+
+// Record the outcome of a failed request, 404 meaning that the code has no page
+func (s *checkpoint) failRequest(code string, r *colly.Response, err error) error {
+	if r.StatusCode == 404 {
+		return s.done(code, outcomeNotFound)
+	}
+	return s.fail(code, err)
+}
+
+// Crawl the codes not finished in the checkpoint, then retry the failed ones with backoff.
+// visit starts the scrape of a code, and wait returns once the scrapes started have finished.
+func crawlCodes(state *checkpoint, codes []string, opts resumeOptions, stop *firstError, visit func(code string) error, wait func()) error {
+	pending := state.pending(codes)
+	log.Printf("Crawling %d of %d codes", len(pending), len(codes))
+
+	for attempt := 0; ; attempt++ {
+		for _, code := range pending {
+			if stop.get() != nil {
+				break
+			}
+			// A failed response is recorded by OnError, and returned again by a synchronous visit
+			start := time.Now()
+			if err := visit(code); err != nil && !state.failedSince(code, start) {
+				if err := state.fail(code, err); err != nil {
+					stop.set(err)
+				}
+			}
+		}
+		wait()
+		saveErr := state.save()
+		if err := stop.get(); err != nil {
+			return err
+		}
+		if saveErr != nil {
+			return saveErr
+		}
+
+		pending = state.pending(pending)
+		if len(pending) == 0 {
+			return nil
+		}
+		if attempt == opts.retries {
+			return fmt.Errorf("%d codes failed, rerun to retry them: see %s", len(pending), state.filename)
+		}
+
+		backoff := opts.backoff << attempt
+		log.Printf("Retrying %d failed codes in %s", len(pending), backoff)
+		time.Sleep(backoff)
+	}
+}
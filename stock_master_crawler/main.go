@@ -1,693 +1,403 @@
+// Command stock_master_crawler crawls the stock master data into the CSV files that the server imports,
+// or straight into the server database, and prints the quotes and news of stocks to check the scrapers.
+//
+// It imports the server module, so the crawled records have the columns of server/stockmaster and go
+// through the same parsing as an import of the CSV files. The fundamentals and profiles crawls send
+// -parallel requests at a time, each code carrying its partial record in the context of its own requests,
+// and keep a checkpoint next to the sink so that an interrupted crawl run again picks up the codes left.
+// A sqlite sink saves each crawl as one import with a crawl- batch; missing codes are not marked as
+// delisted, as a crawl may cover a part of the codes.
 package main
 
 import (
-	"encoding/csv"
-	"encoding/json"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"math/rand/v2"
 	"os"
-	"strconv"
-	"strings"
+	"sync"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
+	"golang.org/x/time/rate"
+
+	"server/config"
+	"server/marketdata"
 )
 
-func bloomTopNews() {
-	// Colly Instance
-	c := colly.NewCollector(
-		colly.AllowedDomains("www.bloomberg.co.jp"), // Restrict to specific domain
-	)
+// Base URLs of the crawled sites, read by loadBaseURLs.
+// Point them at a local server with the same markup to crawl saved pages.
+var bloombergBaseURL, minkabuBaseURL, yahooBaseURL string
 
-	// Limit the request rate
-	c.Limit(&colly.LimitRule{
-		DomainGlob: "*bloomberg.co.jp",
-		Delay:      2 * time.Second, // Set a delay between requests
-	})
+func loadBaseURLs() {
+	bloombergBaseURL = config.Getenv("BLOOMBERG_BASE_URL", "https://www.bloomberg.co.jp")
+	minkabuBaseURL = config.Getenv("MINKABU_BASE_URL", "https://minkabu.jp")
+	yahooBaseURL = config.Getenv("YAHOO_BASE_URL", "https://finance.yahoo.co.jp")
+}
 
-	// Extract specific elements
-	c.OnHTML("title", func(e *colly.HTMLElement) {
-		fmt.Println("Page Title:", e.Text)
-	})
+// Exit codes
+const (
+	exitOK    = 0
+	exitError = 1 // The crawl failed
+	exitUsage = 2 // Unknown command or invalid flags
+)
 
-	// Extract and print all links
-	c.OnHTML("a[href]", func(e *colly.HTMLElement) {
-		link := e.Attr("href")                      // Get the href attribute
-		absoluteURL := e.Request.AbsoluteURL(link)  // Convert to absolute URL
-		title := e.Text                             // Get the link text (title)
-		title = strings.TrimSpace(title)            // Remove leading and trailing white spaces
-		title = strings.ReplaceAll(title, "\n", "") // Remove newlines
-		title = strings.ReplaceAll(title, "\t", "") // Remove tabs
-		if title != "" && strings.Contains(absoluteURL, "https://www.bloomberg.co.jp/news/articles") {
-			fmt.Printf("Link found: %s\nTitle: %s\n", absoluteURL, title)
-		}
-	})
+// Error in the command line
+type usageError struct {
+	err      error
+	reported bool // Already printed with the usage by the flag package
+}
 
-	// Error Handling
-	c.OnError(func(r *colly.Response, err error) {
-		log.Println("Request failed:", err)
-	})
+func (e usageError) Error() string { return e.err.Error() }
 
-	// Visiting
-	err := c.Visit("https://www.bloomberg.co.jp/")
-	if err != nil {
-		log.Fatal(err)
-	}
+// Subcommand of the crawler
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
 }
 
-// Struct to parse JSON-LD data
-type NewsArticle struct {
-	Description string `json:"description"`
+var commands = []command{
+	{"fundamentals", "Crawl the listed stocks and their fundamentals from minkabu into a CSV", runFundamentals},
+	{"profiles", "Crawl the company profiles from Yahoo Finance into a CSV", runProfiles},
+	{"listed", "Print the listed stocks of a code range from minkabu", runListed},
+	{"quote", "Print the daily values of stocks from minkabu", runQuote},
+	{"news", "Save the disclosures and press releases of a stock from minkabu as JSON", runNews},
+	{"bloomberg", "Print the top news of Bloomberg", runBloomberg},
 }
 
-func bloomTopNewsDescription() {
-	// Create a new Colly collector
-	c := colly.NewCollector(
-		colly.AllowedDomains("www.bloomberg.co.jp"), // Restrict crawling to specific domain
-		// colly.CacheDir("./colly_cache"),             // Use cache to prevent duplicate visits
-	)
-
-	// Limit request rate to avoid overloading the server
-	c.Limit(&colly.LimitRule{
-		DomainGlob:  "*bloomberg.co.jp",
-		Delay:       2 * time.Second, // Delay between requests
-		RandomDelay: 1 * time.Second, // Random delay for load balancing
-	})
-
-	// Extract and print the page title
-	c.OnHTML("title", func(e *colly.HTMLElement) {
-		fmt.Println("Page Title:", e.Text)
-	})
-
-	// Extract and visit article links
-	c.OnHTML("a[href]", func(e *colly.HTMLElement) {
-		if e.Request.URL.String() == "https://www.bloomberg.co.jp/" {
-			link := e.Attr("href")
-			absoluteURL := e.Request.AbsoluteURL(link) // Convert to absolute URL
-			title := strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(e.Text, "\n", ""), "\t", ""))
-
-			if title != "" && strings.Contains(absoluteURL, "https://www.bloomberg.co.jp/news/articles") {
-				// fmt.Printf("Link found: %s\nTitle: %s\n", absoluteURL, title)
-
-				// Visit the link if it hasn't been visited already
-				visited, err := c.HasVisited(absoluteURL)
-				if err != nil {
-					log.Println("Error checking visit status:", err)
-					return
-				}
-				if !visited {
-					fmt.Printf("Visiting: %s\n", absoluteURL)
-					fmt.Printf("Article found: %s\n", title)
-					err := c.Visit(absoluteURL)
-					if err != nil {
-						log.Println("Visit failed:", err)
-					}
-				}
-			}
-		}
-	})
+// Options shared by the crawling commands
+type crawlOptions struct {
+	delay       time.Duration
+	parallelism int
+	cacheDir    string
+}
 
-	// Extract description from JSON-LD script
-	c.OnHTML(`script[type="application/ld+json"]`, func(e *colly.HTMLElement) {
-		var article NewsArticle
-		err := json.Unmarshal([]byte(e.Text), &article)
-		if err == nil && article.Description != "" {
-			fmt.Println("Description:", article.Description)
-			fmt.Println("--------------------------------------------------")
-		}
-	})
+func (o *crawlOptions) register(fs *flag.FlagSet, cacheDir string, parallelism int) {
+	o.registerRate(fs, parallelism)
+	fs.StringVar(&o.cacheDir, "cache", cacheDir, "directory to cache the pages in, empty to disable")
+}
 
-	// Handle errors during the request
-	c.OnError(func(r *colly.Response, err error) {
-		log.Printf("Request URL: %s failed with response: %v\nError: %v", r.Request.URL, r, err)
-	})
+// Register the rate flags only, for the commands scraping through the marketdata scrapers, which cache no pages
+func (o *crawlOptions) registerRate(fs *flag.FlagSet, parallelism int) {
+	fs.DurationVar(&o.delay, "delay", 2*time.Second, "delay between requests, plus up to half of it at random")
+	fs.IntVar(&o.parallelism, "parallel", parallelism, "number of concurrent requests to the site")
+}
 
-	// Start crawling from the homepage
-	err := c.Visit("https://www.bloomberg.co.jp/")
-	if err != nil {
-		log.Fatal(err)
+func (o *crawlOptions) validate() error {
+	if o.delay < 0 {
+		return errors.New("-delay must not be negative")
 	}
+	if o.parallelism < 1 {
+		return errors.New("-parallel must be at least 1")
+	}
+	return nil
 }
 
-// Get listed stocks
-func minkabuListedStocks() {
+// New collector for the site of baseURL with the rate limit of the options.
+// Requests run concurrently when parallelism is above 1; call Wait after visiting.
+func (o crawlOptions) collector(baseURL string) (*colly.Collector, error) {
 	c := colly.NewCollector(
-		colly.AllowedDomains("minkabu.jp"),
-		colly.CacheDir("./colly_cache"), // Prevent duplicate visits
+		colly.AllowedDomains(marketdata.Hostname(baseURL)),
 	)
-
-	c.Limit(&colly.LimitRule{
-		DomainGlob:  "*minkabu.jp",
-		Delay:       2 * time.Second,
-		RandomDelay: 1 * time.Second,
+	c.CacheDir = o.cacheDir
+	c.Async = o.parallelism > 1
+
+	err := c.Limit(&colly.LimitRule{
+		DomainGlob:  "*",
+		Delay:       o.delay,
+		RandomDelay: o.delay / 2,
+		Parallelism: o.parallelism,
 	})
-
-	// Extract stock information
-	c.OnHTML("div.md_stockBoard", func(e *colly.HTMLElement) {
-		// Stock code from URL
-		stockCode := strings.Split(e.Request.URL.Path, "/")[2]
-
-		// Listing section (e.g., 東証プライム)
-		listingSection := e.ChildText("div.stock_label")
-		listingSection = strings.TrimSpace(strings.Split(listingSection, "  ")[1]) // Use full-width space
-
-		// Stock name (e.g., メルカリ)
-		stockName := e.ChildText("h2 span.md_stockBoard_stockName")
-
-		// Stock price (e.g., 1,862円)
-		stockPrice := e.ChildText("div.stock_price")
-		stockPrice = strings.ReplaceAll(stockPrice, "\n", "")
-		stockPrice = strings.TrimSpace(stockPrice)
-		stockPrice = strings.TrimSpace(strings.Split(stockPrice, "円")[0])
-		stockPrice = strings.ReplaceAll(stockPrice, ",", "")
-		// Output
-		fmt.Printf("銘柄コード: %s\n上場区分: %s\n銘柄: %s\n株価: %s\n", stockCode, listingSection, stockName, stockPrice)
-		fmt.Println("--------------------------------------------------")
-	})
-
-	// Handle errors
-	c.OnError(func(r *colly.Response, err error) {
-		log.Printf("Failed to crawl %s: %v", r.Request.URL, err)
-	})
-
-	// Crawl stock pages from 1000 to 9999
-	for code := 4350; code <= 4400; code += 5 {
-		url := fmt.Sprintf("https://minkabu.jp/stock/%d", code)
-		c.Visit(url)
+	if err != nil {
+		return nil, err
 	}
+	return c, nil
 }
 
-func minkabuListedStocksFundamental(filename string) {
-	c := colly.NewCollector(
-		colly.AllowedDomains("minkabu.jp"),
-		colly.CacheDir("./colly_cache"),
-	)
-
-	c.Limit(&colly.LimitRule{
-		DomainGlob:  "*minkabu.jp",
-		Delay:       2 * time.Second,
-		RandomDelay: 1 * time.Second,
-	})
-
-	// Write headers to CSV file
-	headers := []string{"銘柄コード", "上場区分", "銘柄", "株価", "社名", "英文社名", "業種", "代表者", "決算", "資本金", "住所", "電話番号(IR)", "上場市場", "上場年月日", "単元株数"}
-	var record []string
-
-	// Extract stock information from the main page
-	c.OnHTML("div.md_stockBoard", func(e *colly.HTMLElement) {
-		stockCode := strings.Split(e.Request.URL.Path, "/")[2]
-		listingSection := e.ChildText("div.stock_label")
-		listingSection = strings.TrimSpace(strings.Split(listingSection, "  ")[1])
-		stockName := e.ChildText("h2 span.md_stockBoard_stockName")
-		stockPrice := e.ChildText("div.stock_price")
-		stockPrice = strings.ReplaceAll(stockPrice, "\n", "")
-		stockPrice = strings.TrimSpace(stockPrice)
-		stockPrice = strings.TrimSpace(strings.Split(stockPrice, "円")[0])
-		stockPrice = strings.ReplaceAll(stockPrice, ",", "")
-
-		// Output
-		fmt.Printf("銘柄コード: %s\n上場区分: %s\n銘柄: %s\n株価: %s\n", stockCode, listingSection, stockName, stockPrice)
-		record = []string{stockCode, listingSection, stockName, stockPrice, "", "", "", "", "", "", "", "", "", "", ""}
-
-		// Visit the fundamental page for more details
-		fundamentalURL := fmt.Sprintf("https://minkabu.jp/stock/%s/fundamental", stockCode)
-		e.Request.Visit(fundamentalURL)
-	})
-
-	// Extract company fundamental information
-	c.OnHTML("dl.md_dataList", func(e *colly.HTMLElement) {
-		e.ForEach("dt", func(_ int, dt *colly.HTMLElement) {
-			label := dt.Text
-			value := dt.DOM.Next().Text()
-			value = strings.TrimSpace(value)
-
-			switch label {
-			case "社名":
-				fmt.Println("社名:", value)
-				record[4] = value
-			case "英文社名":
-				fmt.Println("英文社名:", value)
-				record[5] = value
-			case "業種":
-				fmt.Println("業種:", value)
-				record[6] = value
-			case "代表者":
-				fmt.Println("代表者:", value)
-				record[7] = value
-			case "決算":
-				fmt.Println("決算:", value)
-				record[8] = value
-			case "資本金":
-				fmt.Println("資本金:", value)
-				record[9] = value
-			case "住所":
-				fmt.Println("住所:", value)
-				record[10] = value
-			case "電話番号(IR)":
-				fmt.Println("電話番号(IR):", value)
-				record[11] = value
-			case "上場市場":
-				fmt.Println("上場市場:", value)
-				record[12] = value
-			case "上場年月日":
-				fmt.Println("上場年月日:", value)
-				record[13] = value
-			case "単元株数":
-				fmt.Println("単元株数:", value)
-				record[14] = value
-			}
-		})
-		if record[12] != "" || record[13] != "" || record[14] != "" {
-			writeToCSV(filename, headers, record)
-			fmt.Println("--------------------------------------------------")
-		}
-	})
-
-	c.OnError(func(r *colly.Response, err error) {
-		log.Printf("Failed to crawl %s: %v", r.Request.URL, err)
-	})
+// Queue of the scrapes of the marketdata scrapers, running -parallel at a time and starting one every -delay
+type scrapeQueue struct {
+	delay   time.Duration
+	limiter *rate.Limiter
+	slots   chan struct{}
+	wg      sync.WaitGroup
+}
 
-	for code := 1300; code <= 9999; code++ {
-		url := fmt.Sprintf("https://minkabu.jp/stock/%d", code)
-		c.Visit(url)
+func (o crawlOptions) queue() *scrapeQueue {
+	return &scrapeQueue{
+		delay:   o.delay,
+		limiter: rate.NewLimiter(rate.Every(o.delay), 1),
+		slots:   make(chan struct{}, o.parallelism),
 	}
 }
 
-func yahooFinanceStockProfile(filename string, stock_list [][]string) {
-	// Colly instance
-	c := colly.NewCollector(
-		colly.AllowedDomains("finance.yahoo.co.jp"),
-	)
-
-	// Limit the request rate
-	c.Limit(&colly.LimitRule{
-		DomainGlob:  "*yahoo.co.jp",
-		Delay:       2 * time.Second,
-		RandomDelay: 1 * time.Second,
-	})
-
-	// Write the company name to a CSV file
-	headers := []string{"銘柄コード", "特色", "連結事業", "従業員数（単独）", "従業員数（連結）", "平均年齢", "平均年収"}
-	record := make([]string, len(headers))
+// Start the scrape once a slot is free and the delay has passed, plus up to half of it at random
+func (q *scrapeQueue) Go(scrape func()) {
+	q.slots <- struct{}{}
+	q.limiter.Wait(context.Background())
+	if q.delay > 0 {
+		time.Sleep(rand.N(q.delay/2 + 1))
+	}
 
-	c.OnHTML("span.PriceBoardMain__code__2wso", func(e *colly.HTMLElement) {
-		stockCode := e.Text
-		record[0] = stockCode
-		fmt.Println("銘柄コード:", stockCode)
-	})
+	q.wg.Add(1)
+	go func() {
+		defer func() {
+			<-q.slots
+			q.wg.Done()
+		}()
+		scrape()
+	}()
+}
 
-	// Extract information based on the corresponding table headers
-	c.OnHTML("table.CompanyInformationDetail__table__BIq9", func(e *colly.HTMLElement) {
-		e.ForEach("tr", func(_ int, row *colly.HTMLElement) {
-			header := row.ChildText("th")
-			value := row.ChildText("td")
-
-			// Clean up the extracted text
-			value = strings.TrimSpace(value)
-			value = strings.ReplaceAll(value, "【特色】", "")
-			value = strings.ReplaceAll(value, "【連結事業】", "")
-			if strings.Contains(value, "人") {
-				value = strings.ReplaceAll(value, "人", "")
-				value = strings.ReplaceAll(value, ",", "")
-			}
-			if strings.Contains(value, "歳") {
-				value = strings.ReplaceAll(value, "歳", "")
-			}
-			if strings.Contains(value, "円") {
-				value = strings.ReplaceAll(value, "円", "")
-				value = strings.ReplaceAll(value, ",", "")
-				value = strings.ReplaceAll(value, "千", "000")
-				value = strings.ReplaceAll(value, "百万", "000000")
-			}
+// Wait for the scrapes started
+func (q *scrapeQueue) Wait() {
+	q.wg.Wait()
+}
 
-			switch header {
-			case "特色":
-				record[1] = value
-				fmt.Println("特色:", value)
-			case "連結事業":
-				record[2] = value
-				fmt.Println("連結事業:", value)
-			case "従業員数（単独）":
-				record[3] = value
-				fmt.Println("従業員数（単独）:", value)
-			case "従業員数（連結）":
-				record[4] = value
-				fmt.Println("従業員数（連結）:", value)
-			case "平均年齢":
-				record[5] = value
-				fmt.Println("平均年齢:", value)
-			case "平均年収":
-				record[6] = value
-				fmt.Println("平均年収:", value)
-			}
-		})
+// Codes to crawl, from a range or a list file
+type codeOptions struct {
+	from, to, step int
+	file           string
+}
 
-		// Write the extracted data to a CSV file
-		writeToCSV(filename, headers, record)
-	})
+func (o *codeOptions) register(fs *flag.FlagSet, file string) {
+	fs.IntVar(&o.from, "from", 1300, "first stock code of the range")
+	fs.IntVar(&o.to, "to", 9999, "last stock code of the range")
+	fs.IntVar(&o.step, "step", 1, "step of the code range")
+	fs.StringVar(&o.file, "codes", file, "CSV or text file with a stock code at the head of each line, instead of the range")
+}
 
-	// Error handling
-	c.OnError(func(r *colly.Response, err error) {
-		log.Printf("Failed to crawl %s: %v", r.Request.URL, err)
-	})
+func (o codeOptions) codes() ([]string, error) {
+	if o.file != "" {
+		return readCodes(o.file)
+	}
 
-	for _, stock := range stock_list {
-		code := stock[0]
-		name := stock[2]
-		fmt.Printf("Loading %s....", name)
-		url := fmt.Sprintf("https://finance.yahoo.co.jp/quote/%s.T/profile", code)
-		c.Visit(url)
-		fmt.Println("--------------------------------------------------")
+	if o.from < 1 || o.to < o.from || o.step < 1 {
+		return nil, usageError{err: fmt.Errorf("invalid code range %d..%d step %d", o.from, o.to, o.step)}
+	}
+	var codes []string
+	for code := o.from; code <= o.to; code += o.step {
+		codes = append(codes, fmt.Sprint(code))
 	}
+	return codes, nil
 }
 
-func writeToCSV(filename string, headers []string, record []string) {
-	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		log.Fatalf("Failed to open CSV file: %v", err)
+// Parse the flags of a command, wrapping the errors as usage errors.
+// The validators are methods on pointers to the options, so that they see the parsed values.
+func parseFlags(fs *flag.FlagSet, args []string, validate ...func() error) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err: err, reported: true}
 	}
-	defer file.Close()
+	for _, v := range validate {
+		if err := v(); err != nil {
+			return usageError{err: err}
+		}
+	}
+	return nil
+}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+// Whether the flag is given on the command line
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
 
-	if fileInfo, _ := file.Stat(); fileInfo.Size() == 0 {
-		writer.Write(headers)
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: stock_master_crawler %s %s\n\nFlags:\n", name, usage)
+		fs.PrintDefaults()
 	}
-
-	writer.Write(record)
+	return fs
 }
 
-func readFromCSV(filename string) [][]string {
-	file, err := os.Open(filename)
-	if err != nil {
-		log.Fatalf("Failed to open CSV file: %v", err)
+func runFundamentals(args []string) (err error) {
+	var crawl crawlOptions
+	var codes codeOptions
+	var resume resumeOptions
+	var sinks sinkOptions
+	fs := newFlagSet("fundamentals", "[flags]")
+	crawl.register(fs, "./colly_cache", 4)
+	codes.register(fs, "")
+	resume.register(fs)
+	sinks.register(fs, "stock_fundamental.csv")
+	if err := parseFlags(fs, args, crawl.validate, resume.validate, sinks.validate); err != nil {
+		return err
 	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+	list, err := codes.codes()
+	if err != nil {
+		return err
+	}
+	out, err := sinks.open(stockKind, minkabuBaseURL)
 	if err != nil {
-		log.Fatalf("Failed to read CSV file: %v", err)
+		return err
 	}
+	defer func() { err = out.Finish(err) }()
 
-	return records
+	return minkabuListedStocksFundamental(out, list, crawl, resume)
 }
 
-func stockDailyValue(code string) bool {
-	c := colly.NewCollector(
-		colly.AllowedDomains("minkabu.jp"),
-	)
-
-	c.Limit(&colly.LimitRule{
-		DomainGlob:  "*minkabu.jp",
-		Delay:       2 * time.Second,
-		RandomDelay: 1 * time.Second,
-	})
-
-	// Variables for stock information
-	var stockPrice, marketCap, issuedShares, prevClose, priceChange string
-	stopHigh := false
-
-	// Extract stock information from Minkabu
-	c.OnHTML(".stock_price", func(e *colly.HTMLElement) {
-		stockPrice = strings.TrimSpace(e.Text)
-		stockPrice = strings.ReplaceAll(stockPrice, "\n", "")
-		stockPrice = strings.TrimSpace(strings.Split(stockPrice, "円")[0])
-		stockPrice = strings.ReplaceAll(stockPrice, ",", "")
-	})
-
-	c.OnHTML("table.md_table tbody tr", func(e *colly.HTMLElement) {
-		label := strings.TrimSpace(e.ChildText("th"))
-		value := strings.TrimSpace(e.ChildText("td"))
-
-		// Identify the data based on the label
-		switch label {
-		case "時価総額": // Market capitalization
-			value = strings.ReplaceAll(value, "百万円", "000000")
-			value = strings.ReplaceAll(value, ",", "")
-			marketCap = value
-		case "発行済株数": // Issued shares
-			value = strings.ReplaceAll(value, "千株", "000")
-			value = strings.ReplaceAll(value, ",", "")
-			issuedShares = value
-		}
-	})
+func runProfiles(args []string) (err error) {
+	var crawl crawlOptions
+	var codes codeOptions
+	var resume resumeOptions
+	var sinks sinkOptions
+	fs := newFlagSet("profiles", "[flags]")
+	crawl.registerRate(fs, 4)
+	codes.register(fs, "stock_fundamental.csv")
+	resume.register(fs)
+	sinks.register(fs, "stock_profile.csv")
+	if err := parseFlags(fs, args, crawl.validate, resume.validate, sinks.validate); err != nil {
+		return err
+	}
 
-	c.OnHTML("table.md_table.theme_light tr.ly_vamd", func(e *colly.HTMLElement) {
-		label := strings.TrimSpace(e.ChildText("th"))
-		value := strings.TrimSpace(e.ChildText("td"))
+	out, err := sinks.open(stockDetailKind, yahooBaseURL)
+	if err != nil {
+		return err
+	}
+	defer func() { err = out.Finish(err) }()
+
+	// The database has the stocks to crawl the profiles of, unless a code list is given
+	var list []string
+	if sinks.sqlite() && !isFlagSet(fs, "codes") {
+		list, err = listedStockCodes()
+	} else {
+		list, err = codes.codes()
+	}
+	if err != nil {
+		return err
+	}
+	return yahooFinanceStockProfile(out, list, crawl, resume)
+}
 
-		if strings.Contains(label, "前日終値") { // Match "前日終値"
-			value = strings.ReplaceAll(value, "円", "")
-			value = strings.ReplaceAll(value, ",", "")
-			prevClose = value
-		}
-	})
+func runListed(args []string) error {
+	var crawl crawlOptions
+	var codes codeOptions
+	fs := newFlagSet("listed", "[flags]")
+	crawl.register(fs, "./colly_cache", 1)
+	codes.register(fs, "")
+	if err := parseFlags(fs, args, crawl.validate); err != nil {
+		return err
+	}
 
-	// Extract price change and check if "STOP高" exists
-	c.OnHTML(".md_stockBoard_stockTable", func(e *colly.HTMLElement) {
-		priceChange = strings.TrimSpace(e.ChildText(".stock_price_diff"))
-		if e.ChildText(".hi") == "STOP高" {
-			stopHigh = true
-		}
-	})
+	list, err := codes.codes()
+	if err != nil {
+		return err
+	}
+	return minkabuListedStocks(list, crawl)
+}
 
-	// Variables for financial data
-	var perSum, pbrSum float64
-	var count float64
-
-	// Extract PER and PBR values
-	c.OnHTML("table.md_table tr", func(e *colly.HTMLElement) {
-		cells := e.DOM.Find("td").Map(func(i int, s *goquery.Selection) string {
-			return strings.TrimSpace(s.Text())
-		})
-
-		if len(cells) >= 4 {
-			per, err1 := strconv.ParseFloat(strings.ReplaceAll(cells[2], ",", ""), 64)
-			pbr, err2 := strconv.ParseFloat(strings.ReplaceAll(cells[3], ",", ""), 64)
-			if err1 == nil && err2 == nil {
-				perSum += per
-				pbrSum += pbr
-				count++
-			}
-		}
-	})
+func runQuote(args []string) error {
+	fs := newFlagSet("quote", "CODE...")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usageError{err: errors.New("quote needs at least one stock code")}
+	}
 
-	c.OnScraped(func(r *colly.Response) {
-		if count > 0 {
-			fmt.Println("Stock Information for Code:", code)
-			fmt.Printf("Stock Price: %s\n", stockPrice)
-			fmt.Printf("Market Capitalization: %s\n", marketCap)
-			fmt.Printf("Issued Shares: %s\n", issuedShares)
-			fmt.Printf("Previous Closing Price: %s\n", prevClose)
-			fmt.Printf("Price Change: %s\n", priceChange)
-			if stopHigh {
-				fmt.Println("STOP高: Yes")
-			} else {
-				fmt.Println("STOP高: No")
-			}
-			fmt.Printf("Ave. PER: %.2f\n", perSum/count)
-			fmt.Printf("Ave. PBR: %.2f\n", pbrSum/count)
-		} else {
-			fmt.Println("Loading financial data...")
+	minkabu := marketdata.NewMinkabu(minkabuBaseURL)
+	for _, code := range fs.Args() {
+		quote, err := minkabu.Quote(context.Background(), code)
+		if err != nil {
+			return err
 		}
-	})
-
-	c.OnError(func(r *colly.Response, err error) {
-		log.Printf("Failed to crawl %s: %v", r.Request.URL, err)
-	})
-
-	// Visit the stock page
-	yahoo_url := fmt.Sprintf("https://minkabu.jp/stock/%s", code)
-	c.Visit(yahoo_url)
-	minkabu_url := fmt.Sprintf("https://minkabu.jp/stock/%s/daily_valuation", code)
-	c.Visit(minkabu_url)
-
-	return stopHigh
+		printQuote(quote)
+	}
+	return nil
 }
 
-// Function to check if the date is within this year only
-func isWithinOneYear(dateStr string) (bool, time.Time) {
-	layoutFull := "2006/01/02" // Format for full date (YYYY/MM/DD)
-	// layoutShort := "01/02"     // Format for month/day (MM/DD)
-
-	// Remove time part (HH:mm) if present
-	dateParts := strings.Split(dateStr, " ")
-	cleanDate := dateParts[0] // Extract only the date part
-
-	// Get current date
-	now := time.Now()
-	oneYearAgo := now.AddDate(-1, 0, 0)
-
-	var parsedDate time.Time
-	var err error
-
-	// Try parsing with full date format (YYYY/MM/DD)
-	parsedDate, err = time.Parse(layoutFull, cleanDate)
-	if err == nil {
-		return parsedDate.After(oneYearAgo), parsedDate
+func runNews(args []string) error {
+	var crawl crawlOptions
+	var news newsOptions
+	fs := newFlagSet("news", "[flags] CODE")
+	out := fs.String("out", "", "JSON file to write the articles to (default articles_CODE_DATE.json)")
+	news.register(fs)
+	crawl.registerRate(fs, 1)
+	if err := parseFlags(fs, args, crawl.validate, news.validate); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usageError{err: errors.New("news needs exactly one stock code")}
 	}
 
-	// If parsing fails, try short format (MM/DD) and assume it's from this year or last year
-	thisYear := now.Year()
-	lastYear := now.Year() - 1
-
-	parsedDate, err = time.Parse(layoutFull, fmt.Sprintf("%d/%s", thisYear, cleanDate))
-	if err == nil && parsedDate.After(oneYearAgo) {
-		return true, parsedDate
+	code := fs.Arg(0)
+	filename := *out
+	if filename == "" {
+		filename = fmt.Sprintf("articles_%s_%s.json", code, time.Now().Format("2006-01-02"))
 	}
+	return stockNews(code, filename, news, crawl)
+}
 
-	parsedDate, err = time.Parse(layoutFull, fmt.Sprintf("%d/%s", lastYear, cleanDate))
-	if err == nil && parsedDate.After(oneYearAgo) {
-		return true, parsedDate
+func runBloomberg(args []string) error {
+	fs := newFlagSet("bloomberg", "[flags]")
+	descriptions := fs.Bool("descriptions", false, "visit each article and print its description")
+	if err := parseFlags(fs, args); err != nil {
+		return err
 	}
 
-	log.Printf("Date parsing failed for: %s", dateStr)
-	return false, time.Time{}
+	return bloomTopNews(os.Stdout, *descriptions)
 }
 
-type Article struct {
-	Title  string `json:"title"`
-	Link   string `json:"link"`
-	Source string `json:"source"`
-	Date   string `json:"date"`
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: stock_master_crawler COMMAND [flags]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-13s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'stock_master_crawler COMMAND -h' for the flags of a command.")
 }
 
-func stockNews(code string) {
-	var articles []Article // List to store articles
-
-	baseURL := "https://minkabu.jp/stock/" + code + "/news?page="
-	page := 1
-	stopCrawling := false
+// Run a command and return the exit code
+func run(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return exitUsage
+	}
+	if args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage()
+		return exitOK
+	}
 
-	for {
-		if stopCrawling {
-			break
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
 		}
 
-		// Ex. https://minkabu.jp/stock/4385/news?page=1
-		url := fmt.Sprintf("%s%d", baseURL, page)
-		fmt.Println("Visiting:", url)
-
-		c := colly.NewCollector(
-			colly.AllowedDomains("minkabu.jp"),
-			colly.CacheDir("./colly_cache"),
-		)
-
-		c.Limit(&colly.LimitRule{
-			DomainGlob:  "*minkabu.jp",
-			Delay:       2 * time.Second,
-			RandomDelay: 1 * time.Second,
-		})
-
-		pageHasArticles := false // Track if this page contains valid articles
-
-		// Detect if the page does not exist
-		c.OnHTML(".md_card_ti", func(e *colly.HTMLElement) {
-			if strings.Contains(e.Text, "ページが見つかりませんでした") {
-				fmt.Println("Page not found. Stopping crawl.")
-				stopCrawling = true
+		err := cmd.run(args[1:])
+		var usageErr usageError
+		switch {
+		case err == nil:
+			return exitOK
+		case errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.As(err, &usageErr):
+			if !usageErr.reported {
+				fmt.Fprintln(os.Stderr, "Error:", err)
 			}
-		})
-
-		// Extract news items
-		c.OnHTML("li", func(e *colly.HTMLElement) {
-			title := strings.TrimSpace(e.ChildText(".title_box a"))
-			link := e.ChildAttr(".title_box a", "href")
-			source := strings.TrimSpace(e.ChildText(".fcgl"))
-			date := strings.TrimSpace(e.ChildText(".flex.items-center"))
-
-			// Only process "適時開示" or "PR TIMES" articles
-			if source == "適時開示" || source == "PR TIMES" {
-				isRecent, articleDate := isWithinOneYear(date)
-
-				if !isRecent {
-					fmt.Printf("Found old article (%s), stopping crawl.\n", articleDate.Format("2006/01/02"))
-					stopCrawling = true
-					return
-				}
-
-				fmt.Println("Title:", title)
-				fmt.Println("Link: https://minkabu.jp" + link)
-				fmt.Println("Source:", source)
-				fmt.Println("Date:", date)
-				fmt.Println("----------------------")
-
-				articles = append(articles, Article{
-					Title:  title,
-					Link:   "https://minkabu.jp" + link,
-					Source: source,
-					Date:   date,
-				})
-
-				pageHasArticles = true
-			}
-		})
-
-		if !pageHasArticles {
-			fmt.Println("No articles found on this page.")
-		}
-
-		// Visit the page
-		err := c.Visit(url)
-		if err != nil {
-			log.Fatal(err)
+			return exitUsage
+		default:
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return exitError
 		}
-
-		// Move to the next page, even if the current page has no articles
-		page++
 	}
 
-	// Convert to JSON and write to file
-	writeJSON(articles, code)
-}
-
-// Function to write articles to a JSON file
-func writeJSON(articles []Article, code string) {
-	today := time.Now().Format("2006-01-02")
-	filename := fmt.Sprintf("articles_%s_%s.json", code, today)
-
-	file, err := os.Create(filename)
-	if err != nil {
-		log.Fatal("Failed to create JSON file:", err)
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ") // Pretty print JSON
-	err = encoder.Encode(articles)
-	if err != nil {
-		log.Fatal("Failed to write JSON:", err)
-	}
-
-	fmt.Println("Articles saved to articles.json")
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+	usage()
+	return exitUsage
 }
 
 func main() {
-	// Get the top news from Bloomberg
-	// bloomTopNews()
-	// bloomTopNewsDescription()
-
-	// Get the listed stocks and their fundamental information
-	minkabu_stock_fundamental_filename := "stock_fundamental.csv"
-	minkabuListedStocks()
-	minkabuListedStocksFundamental(minkabu_stock_fundamental_filename)
-
-	// Display the data
-	records := readFromCSV(minkabu_stock_fundamental_filename)
-	for _, record := range records {
-		fmt.Printf("Code: %s\tName: %s\tPrice: %s\n", record[0], record[2], record[3])
-	}
-
-	// Get the stock profile from Yahoo Finance
-	yahoo_finance_stock_profile_filename := "stock_profile.csv"
-	yahooFinanceStockProfile(yahoo_finance_stock_profile_filename, records)
-
-	// Get the daily stock value
-	// mercari_code := "4385"
-	// stockDailyValue(mercari_code)
-
-	// stockNews(mercari_code)
+	loadBaseURLs()
+	os.Exit(run(os.Args[1:]))
 }
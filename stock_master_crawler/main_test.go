@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"server/fixtureserver"
+	"server/stockmaster"
+)
+
+// go test -update rewrites the golden files after a selector change
+var update = flag.Bool("update", false, "rewrite the golden files with the current results")
+
+// Addresses of the fixture servers, replaced with the live ones in the golden files
+var hosts *strings.Replacer
+
+// The crawled sites are served from the pages saved under testdata/html, through the base URLs of the environment
+func TestMain(m *testing.M) {
+	sites := []struct {
+		env, dir, live string
+	}{
+		{"BLOOMBERG_BASE_URL", "bloomberg", "https://www.bloomberg.co.jp"},
+		{"MINKABU_BASE_URL", "minkabu", "https://minkabu.jp"},
+		{"YAHOO_BASE_URL", "yahoo", "https://finance.yahoo.co.jp"},
+	}
+	var replacements []string
+	for _, site := range sites {
+		server := fixtureserver.New(filepath.Join("testdata", "html", site.dir))
+		defer server.Close()
+		os.Setenv(site.env, server.URL)
+		replacements = append(replacements, server.URL, site.live)
+	}
+	hosts = strings.NewReplacer(replacements...)
+	loadBaseURLs()
+
+	code := m.Run()
+	for _, site := range sites {
+		os.Unsetenv(site.env)
+	}
+	os.Exit(code)
+}
+
+// Run a crawl command without delay or retries, failing the test unless it succeeds
+func runCrawl(t *testing.T, args ...string) {
+	t.Helper()
+	args = append(args, "-delay", "0", "-retries", "0")
+	if code := run(args); code != exitOK {
+		t.Fatalf("%s exited with %d", strings.Join(args, " "), code)
+	}
+}
+
+// A code list file of the codes
+func codesFile(t *testing.T, codes ...string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "codes.txt")
+	if err := os.WriteFile(filename, []byte(strings.Join(codes, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+// The records of a CSV file written by a crawl
+func readRecords(t *testing.T, filename string) []stockmaster.Record {
+	t.Helper()
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []stockmaster.Record
+	for _, row := range rows[1:] {
+		record, err := stockmaster.RecordOf(rows[0], row)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// The records and the checkpoint of a crawl, compared with the golden file
+type crawlResult struct {
+	Records []stockmaster.Record `json:"records"`
+	Done    map[string]string    `json:"done"`
+}
+
+func checkCrawl(t *testing.T, golden, output string) {
+	t.Helper()
+	state, err := resumeOptions{}.load(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := crawlResult{Records: readRecords(t, output), Done: state.Done}
+	fixtureserver.Golden(t, filepath.Join("testdata", "golden", golden), result, hosts, *update)
+}
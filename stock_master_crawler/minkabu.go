@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+
+	"server/marketdata"
+	"server/normalize"
+	"server/stockmaster"
+)
+
+// Listing section from the stock label, e.g. "4385\u00a0 \u00a0 東証プライム" -> "東証プライム"
+func listingSectionOf(label string) string {
+	if parts := strings.Split(label, "\u00a0 \u00a0 "); len(parts) > 1 {
+		return strings.TrimSpace(parts[1])
+	}
+	return ""
+}
+
+// Price of the stock board as the CSV has it, e.g. "1,862円" -> "1862", and "---" for a stock without a price
+func stockBoardPrice(text string) string {
+	price, err := normalize.Price(strings.Split(text, "円")[0])
+	if errors.Is(err, normalize.ErrNoValue) {
+		return "---"
+	} else if err != nil {
+		return strings.TrimSpace(text)
+	}
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
+
+// Get listed stocks
+func minkabuListedStocks(codes []string, opts crawlOptions) error {
+	c, err := opts.collector(minkabuBaseURL)
+	if err != nil {
+		return err
+	}
+
+	// Extract stock information
+	c.OnHTML("div.md_stockBoard", func(e *colly.HTMLElement) {
+		// Stock code from URL
+		stockCode := strings.Split(e.Request.URL.Path, "/")[2]
+
+		// Listing section (e.g., 東証プライム)
+		listingSection := listingSectionOf(e.ChildText("div.stock_label"))
+
+		// Stock name (e.g., メルカリ)
+		stockName := e.ChildText("h2 span.md_stockBoard_stockName")
+
+		// Stock price (e.g., 1,862円)
+		stockPrice := stockBoardPrice(e.ChildText("div.stock_price"))
+		// Output
+		fmt.Printf("銘柄コード: %s\n上場区分: %s\n銘柄: %s\n株価: %s\n", stockCode, listingSection, stockName, stockPrice)
+		fmt.Println("--------------------------------------------------")
+	})
+
+	// Handle errors
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Failed to crawl %s: %v", r.Request.URL, err)
+	})
+
+	// Unlisted codes fail in OnError, so the crawl goes on
+	for _, code := range codes {
+		url := fmt.Sprintf("%s/stock/%s", minkabuBaseURL, code)
+		c.Visit(url)
+	}
+	c.Wait()
+
+	return nil
+}
+
+func minkabuListedStocksFundamental(out sink, codes []string, opts crawlOptions, resume resumeOptions) error {
+	c, err := opts.collector(minkabuBaseURL)
+	if err != nil {
+		return err
+	}
+	c.AllowURLRevisit = true // Failed codes are visited again
+
+	state, err := resume.load(out.Name())
+	if err != nil {
+		return err
+	}
+	// Codes written before a crash that the checkpoint missed
+	for _, code := range out.Codes() {
+		if err := state.done(code, outcomeSaved); err != nil {
+			return err
+		}
+	}
+
+	// A write error stops the crawl
+	var writeErr firstError
+
+	// Extract stock information from the main page
+	c.OnHTML("div.md_stockBoard", func(e *colly.HTMLElement) {
+		// The fundamental page has the stock board too
+		if strings.HasSuffix(e.Request.URL.Path, "/fundamental") {
+			return
+		}
+
+		// Each code has its own context, as the pages are scraped concurrently
+		stockCode := e.Request.Ctx.Get("code")
+		listingSection := listingSectionOf(e.ChildText("div.stock_label"))
+		stockName := e.ChildText("h2 span.md_stockBoard_stockName")
+		stockPrice := stockBoardPrice(e.ChildText("div.stock_price"))
+
+		record := stockmaster.Record{
+			stockmaster.StockCode:      stockCode,
+			stockmaster.ListingSection: listingSection,
+			stockmaster.StockName:      stockName,
+			stockmaster.StockPrice:     stockPrice,
+		}
+
+		// Visit the fundamental page for more details, passing the partial record in the context,
+		// which the fundamental request shares
+		e.Request.Ctx.Put("record", record)
+		fundamentalURL := fmt.Sprintf("%s/stock/%s/fundamental", minkabuBaseURL, stockCode)
+		if err := e.Request.Visit(fundamentalURL); err != nil {
+			if err := state.fail(stockCode, err); err != nil {
+				writeErr.set(err)
+			}
+		}
+	})
+
+	// Extract company fundamental information
+	c.OnHTML("dl.md_dataList", func(e *colly.HTMLElement) {
+		record, ok := e.Request.Ctx.GetAny("record").(stockmaster.Record)
+		if !ok {
+			return
+		}
+
+		// The labels of the data list are the columns of the stocks
+		e.ForEach("dt", func(_ int, dt *colly.HTMLElement) {
+			label := dt.Text
+			value := dt.DOM.Next().Text()
+			value = strings.TrimSpace(value)
+
+			if stockmaster.IsColumn(stockmaster.StockColumns, label) {
+				record[label] = value
+			}
+		})
+		outcome := outcomeNoData
+		if record[stockmaster.ListingMarket] != "" || record[stockmaster.ListingDate] != "" || record[stockmaster.UnitShares] != "" {
+			if err := out.Write(record); err != nil {
+				writeErr.set(err)
+				return
+			}
+			outcome = outcomeSaved
+			printRecord(record, stockmaster.StockColumns)
+		}
+		e.Request.Ctx.Put("finished", outcome)
+		if err := state.done(record[stockmaster.StockCode], outcome); err != nil {
+			writeErr.set(err)
+		}
+	})
+
+	// Nothing to write for a stock page without the stock board, or a fundamental page without the data list
+	c.OnScraped(func(r *colly.Response) {
+		fundamental := strings.HasSuffix(r.Request.URL.Path, "/fundamental")
+		if r.Ctx.GetAny("record") == nil || (fundamental && r.Ctx.Get("finished") == "") {
+			if err := state.done(r.Ctx.Get("code"), outcomeNoData); err != nil {
+				writeErr.set(err)
+			}
+		}
+	})
+
+	c.OnError(func(r *colly.Response, err error) {
+		log.Printf("Failed to crawl %s: %v", r.Request.URL, err)
+		if err := state.failRequest(r.Ctx.Get("code"), r, err); err != nil {
+			writeErr.set(err)
+		}
+	})
+
+	// In async mode every code is queued at once, so the queued requests are dropped after an error
+	c.OnRequest(func(r *colly.Request) {
+		if writeErr.get() != nil {
+			r.Abort()
+		}
+	})
+
+	return crawlCodes(state, codes, resume, &writeErr, func(code string) error {
+		ctx := colly.NewContext()
+		ctx.Put("code", code)
+		url := fmt.Sprintf("%s/stock/%s", minkabuBaseURL, code)
+		return c.Request("GET", url, nil, ctx, nil)
+	}, c.Wait)
+}
+
+// Print the quote of a stock
+func printQuote(quote marketdata.Quote) {
+	fmt.Println("Stock Information for Code:", quote.Code)
+	fmt.Printf("Stock Price: %g\n", quote.StockPrice)
+	fmt.Printf("Market Capitalization: %d\n", quote.MarketCap)
+	fmt.Printf("Issued Shares: %d\n", quote.IssuedShares)
+	fmt.Printf("Previous Closing Price: %g\n", quote.PrevClose)
+	fmt.Printf("Price Change: %+g (%+.2f%%)\n", quote.PriceChange, quote.PriceChangePercent)
+	if quote.StopHigh {
+		fmt.Println("STOP高: Yes")
+	} else {
+		fmt.Println("STOP高: No")
+	}
+	fmt.Printf("Ave. PER: %.2f\n", quote.AveragePER)
+	fmt.Printf("Ave. PBR: %.2f\n", quote.AveragePBR)
+}
+
+// Articles that the news command keeps
+type newsOptions struct {
+	sources    string
+	days       int
+	maxPages   int
+	emptyPages int
+}
+
+func (o *newsOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.sources, "sources", strings.Join(marketdata.DefaultNewsSources, ","), "comma separated sources of the articles to keep, empty for all of them")
+	fs.IntVar(&o.days, "days", 365, "stop at the first article published more than this many days ago")
+	fs.IntVar(&o.maxPages, "max-pages", 0, "news pages to read at most, 0 for no limit")
+	fs.IntVar(&o.emptyPages, "empty-pages", marketdata.DefaultEmptyPageStreak, "stop after this many pages in a row without articles")
+}
+
+func (o *newsOptions) validate() error {
+	if o.days < 1 {
+		return errors.New("-days must be at least 1")
+	}
+	if o.maxPages < 0 {
+		return errors.New("-max-pages must not be negative")
+	}
+	if o.emptyPages < 1 {
+		return errors.New("-empty-pages must be at least 1")
+	}
+	return nil
+}
+
+// Options of the pager, with the sources to keep and nil for all of them
+func (o newsOptions) pagerOptions() marketdata.NewsOptions {
+	sources := []string{}
+	for _, source := range strings.Split(o.sources, ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			sources = append(sources, source)
+		}
+	}
+	return marketdata.NewsOptions{
+		Sources:    sources,
+		Window:     time.Duration(o.days) * 24 * time.Hour,
+		MaxPages:   o.maxPages,
+		EmptyPages: o.emptyPages,
+	}
+}
+
+// The pages are read -parallel at a time, one every -delay, and the articles are kept in page order
+func stockNews(code, filename string, news newsOptions, opts crawlOptions) error {
+	minkabu := marketdata.NewMinkabu(minkabuBaseURL)
+	minkabu.NewsPager = marketdata.Pager{Parallelism: opts.parallelism, Interval: opts.delay}
+	articles, err := minkabu.News(context.Background(), code, news.pagerOptions())
+	if err != nil {
+		return err
+	}
+
+	for _, article := range articles {
+		fmt.Println("Title:", article.Title)
+		fmt.Println("Link:", article.Link)
+		fmt.Println("Source:", article.Source)
+		fmt.Println("Date:", article.Date)
+		fmt.Println("----------------------")
+	}
+
+	// Convert to JSON and write to file
+	return writeJSON(articles, filename)
+}
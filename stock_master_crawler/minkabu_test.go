@@ -0,0 +1,13 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// The stock board and the fundamental data list of 4385 are saved, 9999 has no page
+func TestFundamentals(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "stock_fundamental.csv")
+	runCrawl(t, "fundamentals", "-codes", codesFile(t, "4385", "9999"), "-sink", "csv:"+output, "-cache", "")
+	checkCrawl(t, "fundamentals.json", output)
+}
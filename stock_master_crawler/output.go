@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"server/marketdata"
+	"server/stockmaster"
+)
+
+// CSV file of records keyed by the stock code in the first column, that records are appended to.
+// The header is written first if the file is new, and a code already in the file is not written again.
+// Records can be written from concurrent callbacks.
+type csvOutput struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+	codes  map[string]bool
+}
+
+func openCSV(filename string, headers []string) (*csvOutput, error) {
+	codes, err := dedupeCSV(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+
+	out := &csvOutput{file: file, writer: csv.NewWriter(file), codes: codes}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if fileInfo.Size() == 0 {
+		if err := out.writeLocked(headers); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Codes already in the file
+func (o *csvOutput) Codes() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	codes := make([]string, 0, len(o.codes))
+	for code := range o.codes {
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// Write a record unless its code is already in the file
+func (o *csvOutput) Write(record []string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.codes[record[0]] {
+		return nil
+	}
+	if err := o.writeLocked(record); err != nil {
+		return err
+	}
+	o.codes[record[0]] = true
+	return nil
+}
+
+// Write a record and flush it, so that an interrupted crawl keeps what it got
+func (o *csvOutput) writeLocked(record []string) error {
+	if err := o.writer.Write(record); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	o.writer.Flush()
+	if err := o.writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	return nil
+}
+
+func (o *csvOutput) Close() error {
+	return o.file.Close()
+}
+
+// Remove the rows of the same code but the last one from an existing CSV file, as left by
+// crawls that were restarted from scratch, and return the codes in the file
+func dedupeCSV(filename string) (map[string]bool, error) {
+	codes := map[string]bool{}
+	file, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return codes, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CSV file: %w", err)
+	}
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+	if len(records) < 2 {
+		return codes, nil
+	}
+
+	// Keep the position of the first row and the values of the last one
+	latest := map[string]int{}
+	var rows [][]string
+	for _, record := range records[1:] {
+		code := record[0]
+		if i, ok := latest[code]; ok {
+			rows[i] = record
+			continue
+		}
+		latest[code] = len(rows)
+		rows = append(rows, record)
+		codes[code] = true
+	}
+	duplicates := len(records) - 1 - len(rows)
+	if duplicates == 0 {
+		return codes, nil
+	}
+
+	tmp := filename + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dedupe CSV file: %w", err)
+	}
+	writer := csv.NewWriter(out)
+	writer.Write(records[0])
+	err = writer.WriteAll(rows)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to dedupe CSV file: %w", err)
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		return nil, fmt.Errorf("failed to dedupe CSV file: %w", err)
+	}
+	log.Printf("Removed %d duplicate rows from %s", duplicates, filename)
+	return codes, nil
+}
+
+// Print the values of a record at once, so that the records of concurrent requests do not interleave
+func printRecord(record stockmaster.Record, columns []string) {
+	var b strings.Builder
+	for _, column := range columns {
+		if value, ok := record[column]; ok {
+			fmt.Fprintf(&b, "%s: %s\n", column, value)
+		}
+	}
+	b.WriteString("--------------------------------------------------\n")
+	fmt.Print(b.String())
+}
+
+// First error reported by the callbacks, which may run concurrently
+type firstError struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (f *firstError) set(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err == nil {
+		f.err = err
+	}
+}
+
+func (f *firstError) get() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+// Read the stock codes at the head of each line of a CSV or text file, skipping the header and blank lines
+func readCodes(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open code list: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read code list: %w", err)
+	}
+
+	var codes []string
+	for _, record := range records {
+		if len(record) == 0 {
+			continue
+		}
+		code := strings.TrimSpace(record[0])
+		if _, err := strconv.Atoi(code); err != nil {
+			continue
+		}
+		codes = append(codes, code)
+	}
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("no stock codes in %s", filename)
+	}
+	return codes, nil
+}
+
+// Write the articles to a JSON file
+func writeJSON(articles []marketdata.Article, filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create JSON file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ") // Pretty print JSON
+	if err := encoder.Encode(articles); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+
+	fmt.Println("Articles saved to", filename)
+	return nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"server/db"
+	"server/models"
+	"server/repository"
+	"server/stockmaster"
+)
+
+// Destination of the crawled records
+type sink interface {
+	Name() string    // File the checkpoint is named after
+	Codes() []string // Codes already stored, that a resumed crawl skips
+	Write(record stockmaster.Record) error
+	Finish(crawlErr error) error // Close the sink at the end of the crawl, returning crawlErr first
+}
+
+// Error of a record that does not parse, which skips the record rather than stopping the crawl
+type recordError struct {
+	err error
+}
+
+func (e recordError) Error() string { return e.err.Error() }
+
+// Kind of the crawled records
+type masterKind struct {
+	columns []string
+	table   string
+	upsert  func(record stockmaster.Record, batch string) (repository.ImportOutcome, error)
+}
+
+var (
+	stockKind = masterKind{
+		columns: stockmaster.StockColumns,
+		table:   "stocks",
+		upsert: func(record stockmaster.Record, batch string) (repository.ImportOutcome, error) {
+			stock, err := stockmaster.ParseStock(record)
+			if err != nil {
+				return 0, recordError{err}
+			}
+			return repository.UpsertStock(&stock, batch)
+		},
+	}
+	stockDetailKind = masterKind{
+		columns: stockmaster.StockDetailColumns,
+		table:   "stock_details",
+		upsert: func(record stockmaster.Record, batch string) (repository.ImportOutcome, error) {
+			stockDetail, err := stockmaster.ParseStockDetail(record)
+			if err != nil {
+				return 0, recordError{err}
+			}
+			return repository.UpsertStockDetail(&stockDetail, batch)
+		},
+	}
+)
+
+// Where the records are written, csv:FILE or sqlite:FILE
+type sinkOptions struct {
+	spec string
+}
+
+func (o *sinkOptions) register(fs *flag.FlagSet, csvFile string) {
+	fs.StringVar(&o.spec, "sink", "csv:"+csvFile, "csv:FILE to append the records to a CSV file, or sqlite:FILE to upsert them into the server database")
+}
+
+func (o sinkOptions) parse() (kind, path string, err error) {
+	kind, path, ok := strings.Cut(o.spec, ":")
+	if !ok || path == "" || (kind != "csv" && kind != "sqlite") {
+		return "", "", fmt.Errorf("invalid -sink %q, expected csv:FILE or sqlite:FILE", o.spec)
+	}
+	return kind, path, nil
+}
+
+func (o *sinkOptions) validate() error {
+	_, _, err := o.parse()
+	return err
+}
+
+// Whether the records go to the server database
+func (o sinkOptions) sqlite() bool {
+	kind, _, _ := o.parse()
+	return kind == "sqlite"
+}
+
+// Open the sink for records of the kind, from the site of source
+func (o sinkOptions) open(kind masterKind, source string) (sink, error) {
+	sinkKind, path, err := o.parse()
+	if err != nil {
+		return nil, err
+	}
+	if sinkKind == "csv" {
+		return openCSVSink(path, kind.columns)
+	}
+	return openSQLiteSink(path, kind, source)
+}
+
+// CSV file of the records, in the columns of the kind
+type csvSink struct {
+	*csvOutput
+	filename string
+	columns  []string
+}
+
+func openCSVSink(filename string, columns []string) (*csvSink, error) {
+	out, err := openCSV(filename, columns)
+	if err != nil {
+		return nil, err
+	}
+	return &csvSink{csvOutput: out, filename: filename, columns: columns}, nil
+}
+
+func (s *csvSink) Name() string { return s.filename }
+
+func (s *csvSink) Write(record stockmaster.Record) error {
+	return s.csvOutput.Write(record.Row(s.columns))
+}
+
+func (s *csvSink) Finish(crawlErr error) error {
+	if err := s.Close(); err != nil && crawlErr == nil {
+		return err
+	}
+	return crawlErr
+}
+
+// Server database that the records are upserted into, as one import of the master data
+type sqliteSink struct {
+	mu     sync.Mutex // SQLite takes one writer at a time
+	path   string
+	kind   masterKind
+	imp    *models.StockMasterImport
+	report *repository.ImportReport
+}
+
+func openSQLiteSink(path string, kind masterKind, source string) (*sqliteSink, error) {
+	var err error
+	if db.DB, err = db.Open(path + "?_busy_timeout=5000"); err != nil { // Wait for the server writing at the same time
+		return nil, err
+	}
+	// Without FTS5 the server falls back to LIKE, and rebuilds the index when it starts
+	if err := repository.InitStockSearchIndex(); err != nil {
+		log.Println("WARNING: stock search index not updated (build with -tags sqlite_fts5):", err)
+	}
+
+	imp := &models.StockMasterImport{Batch: "crawl-" + time.Now().Format("20060102T150405.000")}
+	if kind.table == stockKind.table {
+		imp.StockFile = source
+	} else {
+		imp.StockDetailFile = source
+	}
+	if err := repository.StartStockMasterImport(imp); err != nil {
+		return nil, err
+	}
+	return &sqliteSink{path: path, kind: kind, imp: imp, report: repository.NewImportReport(imp.Batch)}, nil
+}
+
+// Codes of the stocks in the database that are not delisted
+func listedStockCodes() ([]string, error) {
+	stockCodes, err := repository.GetListedStockCodes()
+	if err != nil {
+		return nil, err
+	}
+	if len(stockCodes) == 0 {
+		return nil, errors.New("no listed stocks in the database, crawl the fundamentals first")
+	}
+	codes := make([]string, len(stockCodes))
+	for i, code := range stockCodes {
+		codes[i] = fmt.Sprint(code)
+	}
+	return codes, nil
+}
+
+func (s *sqliteSink) Name() string { return s.path + "." + s.kind.table }
+
+// Every crawled code is upserted again, so that the database gets the latest values
+func (s *sqliteSink) Codes() []string { return nil }
+
+// Upsert the record; a record that does not parse is reported and skipped
+func (s *sqliteSink) Write(record stockmaster.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	code := record[stockmaster.StockCode]
+	outcome, err := s.kind.upsert(record, s.imp.Batch)
+	var recordErr recordError
+	switch {
+	case errors.As(err, &recordErr):
+		log.Printf("Skipped %s: %v", code, err)
+		s.report.Fail(0, code, recordErr.err)
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to save %s: %w", code, err)
+	}
+	s.report.Count(outcome)
+	return nil
+}
+
+// Save the import with its counts, and the error of the crawl if any
+func (s *sqliteSink) Finish(crawlErr error) error {
+	if err := repository.RebuildStockSearchIndex(); err != nil {
+		log.Println("WARNING: stock search index not updated (build with -tags sqlite_fts5):", err)
+	}
+	err := repository.FinishStockMasterImport(s.imp, crawlErr, s.report)
+	log.Printf("Imported into %s as batch %s: %d inserted, %d updated, %d unchanged, %d failed",
+		s.path, s.imp.Batch, s.imp.Inserted, s.imp.Updated, s.imp.Unchanged, s.imp.Failed)
+
+	if sqlDB, closeErr := db.DB.DB(); closeErr == nil {
+		sqlDB.Close()
+	}
+	return err
+}
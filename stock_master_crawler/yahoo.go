@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"server/marketdata"
+	"server/stockmaster"
+)
+
+func yahooFinanceStockProfile(out sink, codes []string, opts crawlOptions, resume resumeOptions) error {
+	yahoo := marketdata.NewYahoo(yahooBaseURL)
+	queue := opts.queue()
+
+	state, err := resume.load(out.Name())
+	if err != nil {
+		return err
+	}
+	// Codes written before a crash that the checkpoint missed
+	for _, code := range out.Codes() {
+		if err := state.done(code, outcomeSaved); err != nil {
+			return err
+		}
+	}
+
+	// A write error stops the crawl
+	var writeErr firstError
+
+	scrape := func(code string) {
+		fmt.Printf("Loading %s....\n", code)
+		table, err := yahoo.ProfileTable(context.Background(), code)
+		outcome := outcomeSaved
+		switch {
+		case errors.Is(err, marketdata.ErrNotFound):
+			outcome = outcomeNotFound
+		case errors.Is(err, marketdata.ErrNoData):
+			outcome = outcomeNoData
+		case err != nil:
+			if err := state.fail(code, err); err != nil {
+				writeErr.set(err)
+			}
+			return
+		default:
+			// The headers of the table are the columns of the stock details.
+			// The numbers are kept as displayed, e.g. "11,660千円", and normalized by the importer.
+			record := stockmaster.Record{stockmaster.StockCode: code}
+			for header, value := range table {
+				if stockmaster.IsColumn(stockmaster.StockDetailColumns, header) {
+					record[header] = value
+				}
+			}
+			if err := out.Write(record); err != nil {
+				writeErr.set(err)
+				return
+			}
+			printRecord(record, stockmaster.StockDetailColumns)
+		}
+		if err := state.done(code, outcome); err != nil {
+			writeErr.set(err)
+		}
+	}
+
+	return crawlCodes(state, codes, resume, &writeErr, func(code string) error {
+		queue.Go(func() { scrape(code) })
+		return nil
+	}, queue.Wait)
+}
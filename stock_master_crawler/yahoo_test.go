@@ -0,0 +1,13 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// The profile table of 4385 is saved, 9999 has no page
+func TestProfiles(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "stock_profile.csv")
+	runCrawl(t, "profiles", "-codes", codesFile(t, "4385", "9999"), "-sink", "csv:"+output)
+	checkCrawl(t, "profiles.json", output)
+}